@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client names by the SHA-256 of their API key. Empty means the API is open.
+var apiKeys = make(map[[32]byte]string)
+
+// Context key under which the caller's key name is stored
+const apiKeyContextKey = "api_key"
+
+// Add keys given as "name=key" pairs (API_KEYS), comma-separated
+func parseAPIKeys(v string) error {
+	for _, pair := range strings.Split(v, ",") {
+		if err := addAPIKey(strings.TrimSpace(pair)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add keys from a file with one "name=key" per line (API_KEYS_FILE); blank
+// lines and # comments are skipped
+func loadAPIKeysFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := addAPIKey(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func addAPIKey(pair string) error {
+	name, key, ok := strings.Cut(pair, "=")
+	if !ok || name == "" || key == "" {
+		return fmt.Errorf("want name=key")
+	}
+	hash := sha256.Sum256([]byte(key))
+	if other, dup := apiKeys[hash]; dup && other != name {
+		return fmt.Errorf("key of %q is also used by %q", name, other)
+	}
+	apiKeys[hash] = name
+	return nil
+}
+
+// Name of the client a key belongs to, "" when the key is unknown
+func apiKeyOwner(key string) string {
+	if key == "" {
+		return ""
+	}
+	return apiKeys[sha256.Sum256([]byte(key))]
+}
+
+// Name of the key a request was authenticated with, "" when keys are off
+func apiKeyName(c *gin.Context) string {
+	return c.GetString(apiKeyContextKey)
+}
+
+// Require a known key in X-API-Key or an Authorization bearer token and
+// remember its name for logs, jobs and quotas
+func requireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+		if key == "" {
+			c.AbortWithStatusJSON(401, gin.H{"error": apiError("missing_api_key", "API key required")})
+			return
+		}
+		name := apiKeyOwner(key)
+		if name == "" {
+			c.AbortWithStatusJSON(401, gin.H{"error": apiError("invalid_api_key", "Invalid API key")})
+			return
+		}
+		c.Set(apiKeyContextKey, name)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), apiKeyCtxKey{}, name))
+		c.Next()
+	}
+}
+
+type apiKeyCtxKey struct{}
+
+// Name of the key a request or gRPC call was authenticated with, for code
+// that only has its context
+func ctxAPIKeyName(ctx context.Context) string {
+	name, _ := ctx.Value(apiKeyCtxKey{}).(string)
+	return name
+}
+
+// Check the x-api-key (or authorization bearer) metadata of a gRPC call
+func grpcAuthenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		key = v[0]
+	} else if v := md.Get("authorization"); len(v) > 0 {
+		key = strings.TrimPrefix(v[0], "Bearer ")
+	}
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key required")
+	}
+	name := apiKeyOwner(key)
+	if name == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if limit := keyLimit(keyRateLimits, name); limit > 0 {
+		if _, _, ok := keyLimiter.take(name, limit); !ok {
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+	}
+	return context.WithValue(ctx, apiKeyCtxKey{}, name), nil
+}
+
+func grpcUnaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := grpcAuthenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Server stream whose context carries the caller's key name
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authedStream) Context() context.Context { return s.ctx }
+
+func grpcStreamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := grpcAuthenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, authedStream{ss, ctx})
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Most selectors a request may ask for
+const maxDKIMSelectors = 50
+
+// Selectors probed when looking for DKIM keys; DKIM_SELECTORS overrides
+var dkimSelectors = []string{
+	"default", "google", "selector1", "selector2", "k1", "k2", "k3",
+	"s1", "s2", "dkim", "mail", "smtp", "mandrill", "mxvault", "zoho",
+}
+
+// Break an SPF record into its qualifier-tagged terms
+func parseSPF(record string) gin.H {
+	res := gin.H{"record": record}
+	var mechanisms, includes, ips []string
+	all, redirect := "", ""
+	for _, term := range strings.Fields(record)[1:] {
+		lower := strings.ToLower(term)
+		name := strings.TrimLeft(lower, "+-~?")
+		switch {
+		case name == "all":
+			all = lower
+			if lower == "all" {
+				all = "+all" // no qualifier means pass
+			}
+		case strings.HasPrefix(name, "include:"):
+			includes = append(includes, term[strings.Index(term, ":")+1:])
+		case strings.HasPrefix(name, "ip4:"), strings.HasPrefix(name, "ip6:"):
+			ips = append(ips, term[strings.Index(term, ":")+1:])
+		case strings.HasPrefix(name, "redirect="):
+			redirect = term[strings.Index(term, "=")+1:]
+		}
+		mechanisms = append(mechanisms, term)
+	}
+	res["mechanisms"] = mechanisms
+	res["includes"] = includes
+	res["ips"] = ips
+	res["all"] = all
+	if redirect != "" {
+		res["redirect"] = redirect
+	}
+	return res
+}
+
+// SPF facts for a verification result: whether a record exists, its
+// parsed terms and whether it ends in a hard fail (-all)
+func spfSummary(ctx context.Context, domain string) gin.H {
+	record, ok := lookupTXTPrefix(ctx, domain, "v=spf1")
+	if !ok {
+		return gin.H{"exists": false}
+	}
+	res := parseSPF(record)
+	res["exists"] = true
+	res["strict"] = res["all"] == "-all"
+	return res
+}
+
+// Split "k=v; k=v" tag lists used by DMARC and DKIM
+func parseTags(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			tags[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	return tags
+}
+
+// DMARC policy of a domain, nil when none is published
+func lookupDMARC(ctx context.Context, domain string) gin.H {
+	record, ok := lookupTXTPrefix(ctx, "_dmarc."+domain, "v=DMARC1")
+	if !ok {
+		return nil
+	}
+	tags := parseTags(record)
+	res := gin.H{
+		"record": record,
+		"policy": tags["p"],
+		"adkim":  "r",
+		"aspf":   "r",
+	}
+	for _, k := range []string{"sp", "pct", "rua", "ruf", "adkim", "aspf"} {
+		if v, ok := tags[k]; ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// DMARC facts for a verification result, {"exists": false} when the
+// domain publishes no policy
+func dmarcSummary(ctx context.Context, domain string) gin.H {
+	res := lookupDMARC(ctx, domain)
+	if res == nil {
+		return gin.H{"exists": false}
+	}
+	res["exists"] = true
+	return res
+}
+
+// Check each selector for a published DKIM key
+func probeDKIM(ctx context.Context, domain string, selectors []string) []gin.H {
+	found := make([]gin.H, len(selectors))
+	var wg sync.WaitGroup
+	for i, sel := range selectors {
+		wg.Add(1)
+		go func(i int, sel string) {
+			defer wg.Done()
+			txts, err := lookupTXT(ctx, sel+"._domainkey."+domain)
+			if err != nil {
+				return
+			}
+			for _, txt := range txts {
+				if strings.Contains(txt, "p=") {
+					found[i] = gin.H{"selector": sel, "record": txt}
+					return
+				}
+			}
+		}(i, sel)
+	}
+	wg.Wait()
+
+	out := []gin.H{}
+	for _, f := range found {
+		if f != nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Split a comma-separated selector list, dropping blanks and duplicates
+func parseSelectors(raw string) []string {
+	var out []string
+	for _, sel := range strings.Split(raw, ",") {
+		sel = strings.ToLower(strings.TrimSpace(sel))
+		if sel != "" && !slices.Contains(out, sel) {
+			out = append(out, sel)
+		}
+	}
+	return out
+}
+
+// DKIM facts for a verification result: which selectors publish a key
+func dkimSummary(ctx context.Context, domain string) gin.H {
+	found := []string{}
+	for _, key := range probeDKIM(ctx, domain, dkimSelectors) {
+		found = append(found, key["selector"].(string))
+	}
+	return gin.H{"selectors_checked": dkimSelectors, "found": found}
+}
+
+// Look up the domain's authentication records in the background and return
+// a func that waits for them and adds them to a result body. The lookups run
+// once however many bodies the func is applied to.
+func domainAuthSignal(ctx context.Context, domain string) func(body gin.H) {
+	var wg sync.WaitGroup
+	var spf, dmarc, dkim, mtaSTS gin.H
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		spf = spfSummary(ctx, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		dmarc = dmarcSummary(ctx, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		dkim = dkimSummary(ctx, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		mtaSTS = lookupMTASTS(ctx, domain)
+	}()
+	return func(body gin.H) {
+		wg.Wait()
+		body["spf"] = spf
+		body["dmarc"] = dmarc
+		body["dkim"] = dkim
+		body["mta_sts"] = mtaSTS
+	}
+}
+
+// SPF, DMARC, DKIM and MTA-STS picture of a domain
+func domainAuth(ctx context.Context, domain string, selectors []string) gin.H {
+	res := gin.H{"domain": domain, "spf": nil}
+	if record, ok := lookupTXTPrefix(ctx, domain, "v=spf1"); ok {
+		res["spf"] = parseSPF(record)
+	}
+	res["dmarc"] = lookupDMARC(ctx, domain)
+	res["dkim"] = gin.H{
+		"selectors_checked": selectors,
+		"found":             probeDKIM(ctx, domain, selectors),
+	}
+	res["mta_sts"] = lookupMTASTS(ctx, domain)
+	return res
+}
+
+// GET /domain/:domain/auth[?selectors=s1,s2]
+func domainAuthHandler(c *gin.Context) {
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Param("domain"))), "."))
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	selectors := dkimSelectors
+	if raw := c.Query("selectors"); raw != "" {
+		selectors = parseSelectors(raw)
+		if len(selectors) == 0 || len(selectors) > maxDKIMSelectors {
+			c.JSON(400, gin.H{"error": apiError("invalid_selectors", "Invalid selectors"), "max": maxDKIMSelectors})
+			return
+		}
+	}
+	c.JSON(200, domainAuth(c.Request.Context(), domain, selectors))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Avatar lookups are opt-in (GRAVATAR_CHECK=on): they send a hash of every
+// checked address to a third party
+var avatarCheckEnabled bool
+
+// Avatar services queried by hash; each answers 404 for unknown addresses
+// when asked for d=404
+var avatarServices = []struct {
+	name string
+	url  string
+}{
+	{"gravatar", "https://www.gravatar.com/avatar/%s?d=404"},
+	{"libravatar", "https://seccdn.libravatar.org/avatar/%s?d=404"},
+}
+
+var avatarClient = &http.Client{Timeout: 5 * time.Second}
+
+// Look the address up on every avatar service. found lists the services
+// with an avatar; known is false when none of them could be reached.
+func lookupAvatars(ctx context.Context, email string) (found []string, known bool) {
+	sum := sha256.Sum256([]byte(email))
+	hash := hex.EncodeToString(sum[:])
+
+	found = []string{}
+	for _, svc := range avatarServices {
+		req, _ := http.NewRequestWithContext(ctx, "HEAD", fmt.Sprintf(svc.url, hash), nil)
+		resp, err := avatarClient.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case 200:
+			found = append(found, svc.name)
+			known = true
+		case 404:
+			known = true
+		}
+	}
+	return found, known
+}
+
+// Redo the avatar lookup of a shared result for email. Gmail spellings share
+// one probe and cache entry, but avatars are keyed on the exact address.
+func refreshAvatars(ctx context.Context, email string, res gin.H) {
+	if _, ok := res["has_gravatar"]; !ok || !avatarCheckEnabled || !googleMailDomain(ctx, emailDomain(email)) {
+		return
+	}
+	delete(res, "avatars")
+	avatarSignal(ctx, email)(res)
+}
+
+// Start an avatar lookup for email in the background and return a func
+// that waits for it and records has_gravatar and avatars on a result body.
+// Does nothing unless avatar checks are enabled.
+func avatarSignal(ctx context.Context, email string) func(body gin.H) {
+	if !avatarCheckEnabled {
+		return func(gin.H) {}
+	}
+	done := make(chan struct{})
+	var found []string
+	var known bool
+	go func() {
+		found, known = lookupAvatars(ctx, email)
+		close(done)
+	}()
+	return func(body gin.H) {
+		<-done
+		if !known {
+			body["has_gravatar"] = nil // no service answered
+			return
+		}
+		body["has_gravatar"] = slices.Contains(found, "gravatar")
+		body["avatars"] = found
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Largest list accepted by the bulk endpoint
+const maxBulkEmails = 5000
+
+// Domains verified in parallel during a bulk check. Addresses within one
+// domain are checked one after another so its MX isn't hammered.
+var bulkDomainWorkers = 8
+
+// Verify a list of emails, returning one body per input in the same order.
+// progress, if set, is called (possibly concurrently) as each result lands.
+// Addresses not yet checked when ctx ends come back as canceled.
+func checkBulk(ctx context.Context, emails []string, progress func(i int, res gin.H)) []gin.H {
+	out := make([]gin.H, len(emails))
+	byDomain := make(map[string][]int)
+	first := make(map[string]int)
+	dups := make(map[int]int) // duplicate index -> first occurrence
+
+	for i, raw := range emails {
+		email, ok := cleanEmail(raw)
+		if !ok {
+			out[i] = gin.H{"email": raw, "result": "invalid_syntax", "error": apiError("invalid_email", "Invalid email")}
+			if progress != nil {
+				progress(i, out[i])
+			}
+			continue
+		}
+		key := foldGmailDots(ctx, email)
+		if j, seen := first[key]; seen {
+			dups[i] = j
+			continue
+		}
+		first[key] = i
+		if bad := invalidSyntaxResult(email); bad != nil {
+			// No MX lookup for addresses that can't be valid
+			_, res := cachedCheck(ctx, email, func() (int, gin.H) { return 200, bad })
+			res["email"] = email
+			out[i] = res
+			if progress != nil {
+				progress(i, res)
+			}
+			continue
+		}
+		domain := email[strings.LastIndex(email, "@")+1:]
+		byDomain[domain] = append(byDomain[domain], i)
+	}
+
+	sem := make(chan struct{}, bulkDomainWorkers)
+	var wg sync.WaitGroup
+	for domain, idxs := range byDomain {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain string, idxs []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			checkDomainBatch(ctx, domain, idxs, emails, out, progress)
+		}(domain, idxs)
+	}
+	wg.Wait()
+
+	// Duplicates share the result of their first occurrence
+	for i, j := range dups {
+		// Another spelling of the same mailbox keeps its own address fields
+		email, _ := cleanEmail(emails[i])
+		res := addressFields(ctx, email, copyH(out[j]))
+		res["email"] = email
+		refreshAvatars(ctx, email, res)
+		res["duplicate_of"] = j
+		out[i] = res
+		if progress != nil {
+			progress(i, res)
+		}
+	}
+	return out
+}
+
+// Check every address of one domain against a single MX lookup and a single
+// catch-all probe. Each call writes only its own indexes of out.
+func checkDomainBatch(ctx context.Context, domain string, idxs []int, emails []string, out []gin.H, progress func(int, gin.H)) {
+	ascii := asciiDomain(domain) // what DNS and SMTP see of an IDN
+	mxHosts, mxErr := lookupMXHosts(ctx, ascii)
+	var fake *smtpResult
+	addAuth, addAge, addParked := func(gin.H) {}, func(gin.H) {}, func(gin.H) {}
+	if mxErr == nil {
+		addAuth = domainAuthSignal(ctx, ascii)
+		addAge = domainAgeSignal(ctx, ascii)
+		addParked = parkedSignal(ctx, ascii, mxHosts)
+	}
+	// DANE and blacklist lookups, once per MX host that ends up answering
+	type mxSignals struct {
+		dane       func(gin.H, smtpResult)
+		blacklists func(gin.H)
+	}
+	signals := make(map[string]mxSignals)
+
+	for _, i := range idxs {
+		email, _ := cleanEmail(emails[i])
+		status, res := cachedCheck(ctx, email, func() (int, gin.H) {
+			if ctx.Err() != nil {
+				return canceledResult(ctx.Err())
+			}
+			if res := lowQualityResult(email); res != nil {
+				return 200, withDepth(res, depthDeep)
+			}
+			if errors.Is(mxErr, errNullMX) {
+				return 200, withDepth(nullMXResult(email), depthDeep)
+			}
+			if mxErr != nil {
+				return mxErrorResult(email, ascii, mxErr)
+			}
+			rcpts := []string{foldGmailDots(ctx, email[:strings.LastIndex(email, "@")+1]+ascii)}
+			if fake == nil {
+				if r, ok := cachedCatchAllProbe(ascii); ok {
+					fake = &r
+				} else {
+					rcpts = append(rcpts, catchAllProbeAddress(ascii)) // same session as the first address
+				}
+			}
+			release, err := workers.acquire(ctx)
+			if err != nil {
+				return busyResult(err)
+			}
+			defer release()
+			addAvatars := avatarSignal(ctx, email)
+			mxHost, probes := smtpCheckMX(ctx, mxHosts, defaultSMTPTimeouts, rcpts...)
+			if ctx.Err() != nil {
+				return canceledResult(ctx.Err())
+			}
+			probe, catchAll := probes[0], fake
+			if catchAll == nil {
+				catchAll = &probes[1]
+				storeCatchAllProbe(ascii, *catchAll)
+				// Only a definitive answer stands for the rest of the domain;
+				// after a failed session the next address probes again
+				if class := replyClass(rcptCode(*catchAll)); class == "deliverable" || class == "undeliverable" {
+					fake = catchAll
+				}
+			}
+			sig, ok := signals[mxHost]
+			if !ok {
+				sig = mxSignals{daneSignal(mxHost), blacklistSignal(ctx, mxHost)}
+				signals[mxHost] = sig
+			}
+			body := withDepth(buildResult(mxHost, probe, *catchAll), depthDeep)
+			addAvatars(body)
+			addAuth(body)
+			addAge(body)
+			addParked(body)
+			sig.dane(body, probe)
+			sig.blacklists(body)
+			applySpamtrapRisk(email, body)
+			return 200, body
+		})
+		res = applyVerbosity(copyH(res), "standard")
+		res["email"] = email
+		if status != 200 {
+			res["http_status"] = status
+		}
+		out[i] = res
+		if progress != nil {
+			progress(i, res)
+		}
+	}
+}
+
+// POST /email-check/bulk {"emails": ["a@example.com", ...]}
+func bulkCheckHandler(c *gin.Context) {
+	var body struct {
+		Emails []string `json:"emails"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	if len(body.Emails) == 0 {
+		c.JSON(400, gin.H{"error": apiError("no_emails", "No emails provided")})
+		return
+	}
+	if len(body.Emails) > maxBulkEmails {
+		c.JSON(400, gin.H{"error": apiError("too_many_emails", "Too many emails"), "max": maxBulkEmails})
+		return
+	}
+	if !chargeQuota(c, len(body.Emails)) {
+		return
+	}
+
+	results := checkBulk(c.Request.Context(), body.Emails, nil)
+	respond(c, 200, gin.H{"count": len(results), "results": results})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkUnparseableRowHasResult(t *testing.T) {
+	out := checkBulk(context.Background(), []string{"not an address"}, nil)
+	if out[0]["result"] != "invalid_syntax" {
+		t.Errorf("result = %v, want invalid_syntax", out[0]["result"])
+	}
+}
+
+// A catch-all probe that got no definitive answer must not stand in for
+// the rest of the domain
+func TestBulkReprobesAfterIndefiniteCatchAll(t *testing.T) {
+	var rcpts atomic.Int32
+	fakeMX(t, scriptedMX(func(cmd string) string {
+		if strings.HasPrefix(cmd, "RCPT") {
+			if rcpts.Add(1) == 2 { // the first session's made-up recipient
+				return "451 4.3.0 mailbox busy\r\n"
+			}
+			return "250 ok\r\n"
+		}
+		return acceptingMX(cmd)
+	}))
+	fakeMXRecord(t, "batch.example.com", "127.0.0.1")
+	t.Cleanup(func() { purgeCatchAll("batch.example.com") })
+
+	emails := []string{"ann@batch.example.com", "bob@batch.example.com"}
+	out := make([]gin.H, len(emails))
+	checkDomainBatch(context.Background(), "batch.example.com", []int{0, 1}, emails, out, nil)
+	if n := rcpts.Load(); n != 4 {
+		t.Errorf("%d recipients probed, want 4 (the second address probes catch-all again)", n)
+	}
+	if out[1]["catch_all"] != true {
+		t.Errorf("second address catch_all = %v, want true", out[1]["catch_all"])
+	}
+}
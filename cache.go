@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cacheEntry struct {
+	body   gin.H
+	stored time.Time
+}
+
+// Where verification results are cached, keyed by normalized email
+type resultStore interface {
+	get(email string) (cacheEntry, bool)
+	set(email string, body gin.H)
+	purge(email string) bool
+	purgeDomain(domain string) int
+	purgeAll() int
+	size() int
+	ping() error
+}
+
+// How long results are cached (RESULT_CACHE_TTL), 0 when caching is off
+var resultCacheTTL time.Duration
+
+// Cache backend, memory, lru or redis (RESULT_CACHE_BACKEND)
+var resultCacheBackend = "memory"
+
+// Result cache; nil when caching is disabled
+var verifyCache resultStore
+
+// Open the configured result cache, nil when caching is off
+func openResultCache() (resultStore, error) {
+	if resultCacheTTL == 0 {
+		return nil, nil
+	}
+	switch resultCacheBackend {
+	case "redis":
+		return newRedisCache(redisURL, resultCacheTTL)
+	case "lru":
+		return newLRUCache(resultCacheTTL, resultCacheMaxEntries), nil
+	}
+	return newResultCache(resultCacheTTL), nil
+}
+
+// In-memory verification result cache
+type resultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (rc *resultCache) get(email string) (cacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, ok := rc.entries[email]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if time.Since(e.stored) > rc.ttl {
+		delete(rc.entries, email)
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (rc *resultCache) set(email string, body gin.H) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[email] = cacheEntry{body: copyH(body), stored: time.Now()}
+}
+
+// Drop one email; reports whether it was cached
+func (rc *resultCache) purge(email string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	_, ok := rc.entries[email]
+	delete(rc.entries, email)
+	return ok
+}
+
+// Drop every email at domain; returns how many were removed
+func (rc *resultCache) purgeDomain(domain string) int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	n := 0
+	for email := range rc.entries {
+		if strings.HasSuffix(email, "@"+domain) {
+			delete(rc.entries, email)
+			n++
+		}
+	}
+	return n
+}
+
+// Empty the cache; returns how many entries were removed
+func (rc *resultCache) purgeAll() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	n := len(rc.entries)
+	rc.entries = make(map[string]cacheEntry)
+	return n
+}
+
+func (rc *resultCache) size() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.entries)
+}
+
+// Process memory is always there
+func (rc *resultCache) ping() error { return nil }
+
+// Run a check for email through the result cache when it is enabled.
+// Successful responses carry from_cache and cached_age_seconds either way.
+func cachedCheck(ctx context.Context, email string, check func() (int, gin.H)) (int, gin.H) {
+	started := time.Now()
+	status, res := lookupOrCheck(foldGmailDots(ctx, email), check)
+	if res["from_cache"] == true {
+		refreshAvatars(ctx, email, res)
+	}
+	return serveCheck(ctx, email, started, status, res)
+}
+
+// Finish a result for serving: record it in the stats, add the parsed
+// address, score it and log it
+func serveCheck(ctx context.Context, email string, started time.Time, status int, res gin.H) (int, gin.H) {
+	res = addressFields(ctx, email, res)
+	res["score"] = resultScore(res) // at serve time, so cached results follow SCORE_WEIGHTS
+	res["result"] = canonicalResult(res)
+	stats.recordVerification(email, res)
+	recordResultMetrics(res)
+	if history != nil {
+		history.record(foldGmailDots(ctx, email), res)
+	}
+	logVerification(ctx, email, started, res)
+	return status, res
+}
+
+// Reasons that settle an address. Anything else — connection failures,
+// timeouts, 4xx replies, greylisting, open circuits, policy blocks — may
+// come out differently on the next attempt and is never cached.
+var cacheableReasons = map[string]bool{
+	"accepted":         true,
+	"accept_all":       true,
+	"rejected":         true,
+	"mailbox_disabled": true,
+	"vrfy_confirmed":   true,
+	"vrfy_rejected":    true,
+	"invalid_syntax":   true,
+	"null_mx":          true,
+}
+
+func lookupOrCheck(email string, check func() (int, gin.H)) (int, gin.H) {
+	if verifyCache == nil {
+		return check()
+	}
+	if e, ok := verifyCache.get(email); ok {
+		stats.recordCache(true)
+		cacheRequestsTotal.WithLabelValues("hit").Inc()
+		res := copyH(e.body)
+		res["from_cache"] = true
+		res["cached_age_seconds"] = int(time.Since(e.stored).Seconds())
+		return 200, res
+	}
+	stats.recordCache(false)
+	cacheRequestsTotal.WithLabelValues("miss").Inc()
+
+	status, res := check()
+	if status != 200 {
+		return status, res
+	}
+	if reason, _ := res["reason"].(string); cacheableReasons[reason] {
+		verifyCache.set(email, res)
+		res = copyH(res)
+	}
+	res["from_cache"] = false
+	res["cached_age_seconds"] = 0
+	return status, res
+}
+
+// Copy a response body, including every nested map and slice, so cached
+// entries never share mutable state with a response being served
+// concurrently
+func copyH(h gin.H) gin.H {
+	out := make(gin.H, len(h)+2)
+	for k, v := range h {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+// Deep copy of v when it is a map or slice, of any element type; anything
+// else is returned as is
+func copyValue(v interface{}) interface{} {
+	if h, ok := v.(gin.H); ok {
+		return copyH(h)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			cp.SetMapIndex(iter.Key(), copyElem(iter.Value()))
+		}
+		return cp.Interface()
+	case reflect.Slice:
+		if rv.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(copyElem(rv.Index(i)))
+		}
+		return cp.Interface()
+	}
+	return v
+}
+
+// copyValue of a map or slice element, as a value of the element's type
+func copyElem(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Interface && v.IsNil() {
+		return v
+	}
+	return reflect.ValueOf(copyValue(v.Interface())).Convert(v.Type())
+}
+
+// Mirror the cache flags of a response into X-Cache and Age headers
+func setCacheHeaders(c *gin.Context, res gin.H) {
+	hit, ok := res["from_cache"].(bool)
+	if !ok {
+		return
+	}
+	if hit {
+		c.Header("X-Cache", "HIT")
+		c.Header("Age", strconv.Itoa(res["cached_age_seconds"].(int)))
+	} else {
+		c.Header("X-Cache", "MISS")
+	}
+}
+
+// Abort admin cache requests when caching is off
+func requireCache(c *gin.Context) bool {
+	if verifyCache == nil {
+		c.JSON(404, gin.H{"error": apiError("cache_disabled", "Result cache disabled")})
+		return false
+	}
+	return true
+}
+
+// GET /admin/cache
+func cacheInfoHandler(c *gin.Context) {
+	if !requireCache(c) {
+		return
+	}
+	c.JSON(200, gin.H{"backend": resultCacheBackend, "entries": verifyCache.size(), "ttl_seconds": int(resultCacheTTL.Seconds())})
+}
+
+// GET /admin/cache/emails/:email
+func cacheEntryHandler(c *gin.Context) {
+	if !requireCache(c) {
+		return
+	}
+	email, ok := cleanEmail(c.Param("email"))
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_email", "Invalid email")})
+		return
+	}
+	e, ok := verifyCache.get(foldGmailDots(c.Request.Context(), email))
+	if !ok {
+		c.JSON(404, gin.H{"error": apiError("not_cached", "Not cached")})
+		return
+	}
+	age := time.Since(e.stored)
+	c.JSON(200, gin.H{
+		"email":              email,
+		"stored_at":          e.stored,
+		"age_seconds":        int(age.Seconds()),
+		"expires_in_seconds": int((resultCacheTTL - age).Seconds()),
+		"result":             e.body,
+	})
+}
+
+// DELETE /admin/cache/emails/:email
+func cachePurgeEmailHandler(c *gin.Context) {
+	if !requireCache(c) {
+		return
+	}
+	email, ok := cleanEmail(c.Param("email"))
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_email", "Invalid email")})
+		return
+	}
+	n := 0
+	if verifyCache.purge(foldGmailDots(c.Request.Context(), email)) {
+		n = 1
+	}
+	c.JSON(200, gin.H{"email": email, "purged": n})
+}
+
+// DELETE /admin/cache/domains/:domain
+func cachePurgeDomainHandler(c *gin.Context) {
+	if !requireCache(c) {
+		return
+	}
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Param("domain"))), ".")
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	c.JSON(200, gin.H{"domain": domain, "purged": verifyCache.purgeDomain(domain), "catch_all_purged": purgeCatchAll(domain)})
+}
+
+// DELETE /admin/cache
+func cachePurgeAllHandler(c *gin.Context) {
+	if !requireCache(c) {
+		return
+	}
+	c.JSON(200, gin.H{"purged": verifyCache.purgeAll(), "catch_all_purged": purgeCatchAll("")})
+}
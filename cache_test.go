@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Swap in an empty in-memory result cache for the test
+func withResultCache(t *testing.T) {
+	old := verifyCache
+	verifyCache = newResultCache(time.Hour)
+	t.Cleanup(func() { verifyCache = old })
+}
+
+func TestFromCacheMissThenHit(t *testing.T) {
+	withResultCache(t)
+	checks := 0
+	check := func() (int, gin.H) {
+		checks++
+		return 200, gin.H{"reason": "rejected", "reply_class": "undeliverable"}
+	}
+	_, first := cachedCheck(context.Background(), "gone@example.com", check)
+	_, second := cachedCheck(context.Background(), "gone@example.com", check)
+	if first["from_cache"] != false || first["cached_age_seconds"] != 0 {
+		t.Errorf("miss: from_cache = %v, cached_age_seconds = %v", first["from_cache"], first["cached_age_seconds"])
+	}
+	if second["from_cache"] != true {
+		t.Errorf("hit: from_cache = %v, want true", second["from_cache"])
+	}
+	if checks != 1 {
+		t.Errorf("check ran %d times, want 1", checks)
+	}
+}
+
+// Gmail spellings share one cache entry and one normalized_email
+func TestGmailSpellingsShareCacheEntry(t *testing.T) {
+	withResultCache(t)
+	checks := 0
+	check := func() (int, gin.H) {
+		checks++
+		return 200, gin.H{"reason": "accepted", "reply_class": "deliverable"}
+	}
+	cachedCheck(context.Background(), "johndoe@gmail.com", check)
+	_, res := cachedCheck(context.Background(), "john.doe@gmail.com", check)
+	if checks != 1 || res["from_cache"] != true {
+		t.Errorf("check ran %d times, from_cache = %v", checks, res["from_cache"])
+	}
+	if res["normalized_email"] != "johndoe@gmail.com" {
+		t.Errorf("normalized_email = %v, want johndoe@gmail.com", res["normalized_email"])
+	}
+}
+
+// Google Workspace domains fold like gmail.com; other domains keep their dots
+func TestFoldGmailDotsWorkspace(t *testing.T) {
+	fakeMXRecord(t, "corp.example.com", "aspmx.l.google.com")
+	fakeMXRecord(t, "other.example.com", "mx.other.example.com")
+	ctx := context.Background()
+	for in, want := range map[string]string{
+		"j.doe@corp.example.com":   "jdoe@corp.example.com",
+		"j.doe@other.example.com":  "j.doe@other.example.com",
+		"j.o.h.n@googlemail.com":   "john@googlemail.com",
+		`"j.doe"@corp.example.com`: `"j.doe"@corp.example.com`,
+	} {
+		if got := foldGmailDots(ctx, in); got != want {
+			t.Errorf("foldGmailDots(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestTransientVerdictsNotCached(t *testing.T) {
+	for _, reason := range []string{"connection_failed", "timeout", "temporary_failure", "port_25_blocked", "blocked_after_banner", "greylisted", "mx_circuit_open"} {
+		withResultCache(t)
+		checks := 0
+		check := func() (int, gin.H) {
+			checks++
+			return 200, gin.H{"reason": reason, "reply_class": "unknown"}
+		}
+		cachedCheck(context.Background(), "someone@example.com", check)
+		_, res := cachedCheck(context.Background(), "someone@example.com", check)
+		if checks != 2 || res["from_cache"] == true {
+			t.Errorf("%s: cached (checks = %d, from_cache = %v)", reason, checks, res["from_cache"])
+		}
+	}
+}
+
+// Checks of one domain running at once, through the cache, must not share
+// any mutable part of their results; run with -race
+func TestConcurrentSameDomainChecks(t *testing.T) {
+	withResultCache(t)
+	fakeMX(t, scriptedMX(acceptingMX))
+	fakeMXRecord(t, "race.example.com", "localhost")
+	opts := checkOptions{depth: depthDeep, timeouts: testTimeouts()}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for _, email := range []string{"ann@race.example.com", "bob@race.example.com"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := context.Background()
+				_, res := cachedCheck(ctx, email, func() (int, gin.H) { return checkEmail(ctx, email, opts) })
+				if res["reason"] != "accept_all" {
+					t.Errorf("%s: reason = %v, want accept_all", email, res["reason"])
+				}
+				res = applyVerbosity(res, "standard")
+				touch(res) // callers are free to change what they were handed
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestCopyHIsDeep(t *testing.T) {
+	orig := gin.H{
+		"smtp":  gin.H{"banner": "220 hi"},
+		"logs":  map[string]string{"rcpt": "250 ok"},
+		"mx":    []gin.H{{"host": "mx1"}},
+		"names": []string{"a"},
+		"any":   []interface{}{map[string]interface{}{"k": "v"}, nil},
+	}
+	cp := copyH(orig)
+	cp["smtp"].(gin.H)["banner"] = "x"
+	cp["logs"].(map[string]string)["rcpt"] = "x"
+	cp["mx"].([]gin.H)[0]["host"] = "x"
+	cp["names"].([]string)[0] = "x"
+	cp["any"].([]interface{})[0].(map[string]interface{})["k"] = "x"
+	if orig["smtp"].(gin.H)["banner"] != "220 hi" || orig["logs"].(map[string]string)["rcpt"] != "250 ok" ||
+		orig["mx"].([]gin.H)[0]["host"] != "mx1" || orig["names"].([]string)[0] != "a" ||
+		orig["any"].([]interface{})[0].(map[string]interface{})["k"] != "v" {
+		t.Errorf("copy shares state with the original: %v", orig)
+	}
+}
+
+// Write into every map and slice nested in v
+func touch(v interface{}) {
+	switch v := v.(type) {
+	case gin.H:
+		for _, e := range v {
+			touch(e)
+		}
+		v["touched"] = true
+	case map[string]string:
+		v["touched"] = "yes"
+	case []gin.H:
+		for _, e := range v {
+			touch(e)
+		}
+	case []string:
+		for i := range v {
+			v[i] += ""
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Made-up recipients tried by /catch-all-check
+const catchAllProbes = 3
+
+// Name parts for made-up recipients, so probes look like ordinary mailboxes
+var (
+	probeFirstNames = []string{"anna", "james", "laura", "michael", "sofia", "daniel", "emma", "lucas", "olivia", "david", "julia", "thomas"}
+	probeLastNames  = []string{"becker", "mendez", "walsh", "novak", "harper", "rossi", "lindqvist", "moreau", "kowalski", "tanaka", "fischer", "reyes"}
+)
+
+// Random name-like local part (e.g. "laura.mendez.3f9a2c") that is
+// vanishingly unlikely to be a real mailbox; a fresh one on every call
+func randomLocalPart() string {
+	b := make([]byte, 5)
+	rand.Read(b)
+	first := probeFirstNames[int(b[0])%len(probeFirstNames)]
+	last := probeLastNames[int(b[1])%len(probeLastNames)]
+	return first + "." + last + "." + hex.EncodeToString(b[2:])
+}
+
+// How long a domain's catch-all probe is reused; 0 disables (CATCHALL_CACHE_TTL)
+var catchAllCacheTTL = time.Hour
+
+type catchAllEntry struct {
+	res    smtpResult
+	stored time.Time
+}
+
+// Definitive catch-all probe outcomes by domain, so verifying many addresses
+// at one domain sends a single made-up recipient per TTL
+var catchAllCache = struct {
+	mu      sync.Mutex
+	entries map[string]catchAllEntry
+}{entries: make(map[string]catchAllEntry)}
+
+// Fresh cached catch-all probe of domain, if there is one
+func cachedCatchAllProbe(domain string) (smtpResult, bool) {
+	if catchAllCacheTTL <= 0 {
+		return smtpResult{}, false
+	}
+	catchAllCache.mu.Lock()
+	e, ok := catchAllCache.entries[domain]
+	catchAllCache.mu.Unlock()
+	if !ok || time.Since(e.stored) > catchAllCacheTTL {
+		return smtpResult{}, false
+	}
+	res := e.res
+	res.logs = make(map[string]string, len(e.res.logs)+1)
+	for k, v := range e.res.logs {
+		res.logs[k] = v
+	}
+	res.logs["cache"] = "reused probe from " + e.stored.Format(time.RFC3339)
+	return res, true
+}
+
+// Remember a catch-all probe; only accepted or permanently rejected
+// probes are kept
+func storeCatchAllProbe(domain string, res smtpResult) {
+	if class := replyClass(rcptCode(res)); catchAllCacheTTL > 0 && (class == "deliverable" || class == "undeliverable") {
+		res.transcript = nil // the session also carried the address being verified
+		catchAllCache.mu.Lock()
+		catchAllCache.entries[domain] = catchAllEntry{res, time.Now()}
+		catchAllCache.mu.Unlock()
+	}
+}
+
+// Forget cached catch-all probes of one domain, or of all when domain is "";
+// returns how many were dropped
+func purgeCatchAll(domain string) int {
+	catchAllCache.mu.Lock()
+	defer catchAllCache.mu.Unlock()
+	if domain == "" {
+		n := len(catchAllCache.entries)
+		catchAllCache.entries = make(map[string]catchAllEntry)
+		return n
+	}
+	if _, ok := catchAllCache.entries[domain]; ok {
+		delete(catchAllCache.entries, domain)
+		return 1
+	}
+	return 0
+}
+
+// Probe a domain with several random recipients over one SMTP session.
+// Returns true/false, or "unknown" when no probe got a definitive answer or
+// as many were accepted as rejected, plus a 0-1 confidence.
+func detectCatchAll(ctx context.Context, domain, mxHost string) (interface{}, float64, []gin.H) {
+	addrs := make([]string, catchAllProbes)
+	for i := range addrs {
+		addrs[i] = catchAllProbeAddress(domain)
+	}
+	probes := make([]gin.H, 0, catchAllProbes)
+	accepted, rejected := 0, 0
+	for i, res := range smtpSession(ctx, mxHost, defaultSMTPTimeouts, addrs...) {
+		code := rcptCode(res)
+		switch {
+		case code == 250 || code == 251:
+			accepted++
+		case code >= 500:
+			rejected++
+		}
+		probes = append(probes, gin.H{"address": addrs[i], "code": code, "reason": probeReason(res, false)})
+	}
+
+	switch {
+	case accepted == rejected:
+		return "unknown", 0, probes
+	case accepted > rejected:
+		return true, float64(accepted) / catchAllProbes, probes
+	default:
+		return false, float64(rejected) / catchAllProbes, probes
+	}
+}
+
+// POST /catch-all-check {"domain": "example.com"}
+func catchAllCheckHandler(c *gin.Context) {
+	var body struct {
+		Domain string `json:"domain"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(body.Domain)), "."))
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	mxHost, err := lookupMXHost(c.Request.Context(), domain)
+	if err != nil {
+		status, e := mxLookupFailure(domain, err)
+		c.JSON(status, gin.H{"error": e})
+		return
+	}
+
+	release, ok := acquireWorker(c)
+	if !ok {
+		return
+	}
+	defer release()
+	catchAll, confidence, probes := detectCatchAll(c.Request.Context(), domain, mxHost)
+	if abandoned(c, gin.H{"stage": "smtp", "result": gin.H{"domain": domain, "mx_host": mxHost, "probes": probes}}) {
+		return
+	}
+	c.JSON(200, gin.H{
+		"domain":     domain,
+		"mx_host":    mxHost,
+		"catch_all":  catchAll,
+		"confidence": confidence,
+		"probes":     probes,
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// All probes share one session, and a split vote settles nothing
+func TestDetectCatchAllOneSessionTieUnknown(t *testing.T) {
+	var sessions, rcpts atomic.Int32
+	replies := []string{"250 ok\r\n", "550 no such user\r\n", "451 try later\r\n"}
+	fakeMX(t, func(conn net.Conn, r *bufio.Reader) {
+		sessions.Add(1)
+		scriptedMX(func(cmd string) string {
+			if strings.HasPrefix(cmd, "RCPT") {
+				return replies[int(rcpts.Add(1)-1)%len(replies)]
+			}
+			return acceptingMX(cmd)
+		})(conn, r)
+	})
+	catchAll, confidence, probes := detectCatchAll(context.Background(), "example.com", "127.0.0.1")
+	if catchAll != "unknown" || confidence != 0 {
+		t.Errorf("catch_all = %v, confidence = %v, want unknown, 0", catchAll, confidence)
+	}
+	if len(probes) != catchAllProbes || sessions.Load() != 1 {
+		t.Errorf("%d probes over %d sessions, want %d over 1", len(probes), sessions.Load(), catchAllProbes)
+	}
+}
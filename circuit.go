@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Consecutive connection or greeting failures that open an MX host's
+// circuit (MX_CIRCUIT_FAILURES), 0 to never open one
+var mxCircuitFailures = 5
+
+// How long an open circuit fails sessions to its host at once before one is
+// let through to try again (MX_CIRCUIT_COOLDOWN)
+var mxCircuitCooldown = time.Minute
+
+var errCircuitOpen = errors.New("MX host skipped after repeated connection failures")
+
+// Failure streak of one MX host
+type mxCircuit struct {
+	failures  int
+	openUntil time.Time
+	trialAt   time.Time // when the session testing a cooled-down host started
+}
+
+// Circuits of hosts that failed lately; hosts that answer are dropped
+var mxCircuits = struct {
+	sync.Mutex
+	hosts map[string]*mxCircuit
+}{hosts: make(map[string]*mxCircuit)}
+
+// Report whether a session may be opened to host. Once an open circuit
+// cools down a single session is let through; until it ends the rest keep
+// failing fast.
+func circuitAllows(host string) bool {
+	mxCircuits.Lock()
+	defer mxCircuits.Unlock()
+	c, ok := mxCircuits.hosts[host]
+	if !ok || mxCircuitFailures == 0 || c.failures < mxCircuitFailures {
+		return true
+	}
+	now := time.Now()
+	if now.Before(c.openUntil) || now.Sub(c.trialAt) < defaultSMTPTimeouts.session {
+		return false
+	}
+	c.trialAt = now
+	return true
+}
+
+// Count a failed connection or greeting, opening the circuit at the limit
+func circuitFailure(host string) {
+	if mxCircuitFailures == 0 {
+		return
+	}
+	mxCircuits.Lock()
+	defer mxCircuits.Unlock()
+	c, ok := mxCircuits.hosts[host]
+	if !ok {
+		if len(mxCircuits.hosts) > 10000 {
+			for h, old := range mxCircuits.hosts {
+				if time.Now().After(old.openUntil) {
+					delete(mxCircuits.hosts, h)
+				}
+			}
+		}
+		c = &mxCircuit{}
+		mxCircuits.hosts[host] = c
+	}
+	c.failures++
+	c.trialAt = time.Time{}
+	if c.failures >= mxCircuitFailures {
+		if !time.Now().Before(c.openUntil) {
+			slog.Warn("MX circuit open", "mx_host", host, "failures", c.failures, "cooldown", mxCircuitCooldown.String())
+		}
+		c.openUntil = time.Now().Add(mxCircuitCooldown)
+	}
+}
+
+// Close host's circuit after it greeted us
+func circuitSuccess(host string) {
+	mxCircuits.Lock()
+	defer mxCircuits.Unlock()
+	delete(mxCircuits.hosts, host)
+}
+
+// MX hosts whose circuit is open right now
+func openCircuits() int {
+	mxCircuits.Lock()
+	defer mxCircuits.Unlock()
+	n := 0
+	now := time.Now()
+	for _, c := range mxCircuits.hosts {
+		if c.failures >= mxCircuitFailures && now.Before(c.openUntil) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ClassificationProvider answers every "what kind of address is this"
+// question. Swap it out to back classification with a richer source.
+type ClassificationProvider interface {
+	IsDisposable(domain string) bool
+	IsRole(localPart string) bool
+	IsFreeProvider(domain string) bool
+	IsFreeProviderMX(mxHost string) bool
+	Platform(mxHost string) string
+	IsSpamtrapDomain(domain string) bool
+	IsParkingHost(hostOrIP string) bool
+	LowQualityRule(localPart string) string
+	IsToxic(domain string) bool
+}
+
+//go:embed data/*.txt
+var embeddedData embed.FS
+
+// Provider used by all checks
+var classifier ClassificationProvider = mustDefaultClassifier()
+
+// Operator rule flagging a local part as low quality: a whole word, or a
+// /regexp/
+type localPartRule struct {
+	raw  string
+	word string
+	re   *regexp.Regexp
+}
+
+type platformSuffix struct {
+	suffix   string
+	platform string
+}
+
+// Default provider backed by plain-text lists
+type listClassifier struct {
+	disposable map[string]bool
+	role       map[string]bool
+	free       map[string]bool
+	freeMX     map[string]bool
+	platforms  []platformSuffix
+	spamtraps  map[string]bool
+	parking    map[string]bool
+	lowQuality []localPartRule
+	toxic      map[string]bool
+}
+
+func mustDefaultClassifier() *listClassifier {
+	lc, err := loadClassifier("")
+	if err != nil {
+		panic(err)
+	}
+	return lc
+}
+
+// Load lists from dir, falling back to the embedded copy for any file the
+// directory doesn't provide. An empty dir uses the embedded data only.
+func loadClassifier(dir string) (*listClassifier, error) {
+	lc := &listClassifier{}
+	var err error
+	if lc.disposable, err = loadSet(dir, "disposable.txt"); err != nil {
+		return nil, err
+	}
+	if lc.role, err = loadSet(dir, "role.txt"); err != nil {
+		return nil, err
+	}
+	if lc.free, err = loadSet(dir, "free.txt"); err != nil {
+		return nil, err
+	}
+	if lc.freeMX, err = loadSet(dir, "free_mx.txt"); err != nil {
+		return nil, err
+	}
+	if lc.spamtraps, err = loadSet(dir, "spamtraps.txt"); err != nil {
+		return nil, err
+	}
+	if lc.parking, err = loadSet(dir, "parked.txt"); err != nil {
+		return nil, err
+	}
+	// The toxic list only grows: a directory copy adds to the embedded one
+	if lc.toxic, err = loadSet("", "toxic.txt"); err != nil {
+		return nil, err
+	}
+	extra, err := loadSet(dir, "toxic.txt")
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(lc.toxic, extra)
+	rules, err := loadLines(dir, "low_quality.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if len(rule) > 2 && strings.HasPrefix(rule, "/") && strings.HasSuffix(rule, "/") {
+			re, err := regexp.Compile("(?i)" + rule[1:len(rule)-1])
+			if err != nil {
+				return nil, fmt.Errorf("low_quality.txt: %v", err)
+			}
+			lc.lowQuality = append(lc.lowQuality, localPartRule{raw: rule, re: re})
+			continue
+		}
+		lc.lowQuality = append(lc.lowQuality, localPartRule{raw: rule, word: strings.ToLower(rule)})
+	}
+	lines, err := loadLines(dir, "platforms.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		lc.platforms = append(lc.platforms, platformSuffix{strings.ToLower(fields[0]), fields[1]})
+	}
+	return lc, nil
+}
+
+func loadSet(dir, name string) (map[string]bool, error) {
+	lines, err := loadLines(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		set[strings.ToLower(line)] = true
+	}
+	return set, nil
+}
+
+// Read non-empty, non-comment lines of a data file
+func loadLines(dir, name string) ([]string, error) {
+	var r io.ReadCloser
+	var err error
+	if dir != "" {
+		r, err = os.Open(filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if r == nil {
+		r, err = embeddedData.Open("data/" + name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer r.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// Match domain or any parent domain against the set
+func matchDomain(set map[string]bool, domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for domain != "" {
+		if set[domain] {
+			return true
+		}
+		i := strings.IndexByte(domain, '.')
+		if i < 0 {
+			break
+		}
+		domain = domain[i+1:]
+	}
+	return false
+}
+
+func (lc *listClassifier) IsDisposable(domain string) bool {
+	return matchDomain(lc.disposable, domain)
+}
+
+func (lc *listClassifier) IsRole(localPart string) bool {
+	return lc.role[strings.ToLower(localPart)]
+}
+
+func (lc *listClassifier) IsFreeProvider(domain string) bool {
+	return lc.free[strings.TrimSuffix(strings.ToLower(domain), ".")]
+}
+
+// IsFreeProviderMX reports whether mxHost only serves consumer mailboxes,
+// catching free-provider domains the free list doesn't name
+func (lc *listClassifier) IsFreeProviderMX(mxHost string) bool {
+	return matchDomain(lc.freeMX, mxHost)
+}
+
+// Platform returns the mail platform hosting mxHost, or "" if unknown
+func (lc *listClassifier) Platform(mxHost string) string {
+	host := strings.TrimSuffix(strings.ToLower(mxHost), ".")
+	for _, p := range lc.platforms {
+		if host == p.suffix || strings.HasSuffix(host, "."+p.suffix) {
+			return p.platform
+		}
+	}
+	return ""
+}
+
+// IsSpamtrapDomain reports whether domain is known to run spam traps
+func (lc *listClassifier) IsSpamtrapDomain(domain string) bool {
+	return matchDomain(lc.spamtraps, domain)
+}
+
+// IsParkingHost reports whether a name server, MX host or IP address
+// belongs to a domain parking service
+func (lc *listClassifier) IsParkingHost(hostOrIP string) bool {
+	if net.ParseIP(hostOrIP) != nil {
+		return lc.parking[hostOrIP]
+	}
+	return matchDomain(lc.parking, hostOrIP)
+}
+
+// LowQualityRule returns the first rule matching localPart (its +tag
+// ignored), or "" when none does
+func (lc *listClassifier) LowQualityRule(localPart string) string {
+	local, _, _ := strings.Cut(strings.ToLower(localPart), "+")
+	for _, r := range lc.lowQuality {
+		if r.re != nil && r.re.MatchString(local) || r.re == nil && r.word == local {
+			return r.raw
+		}
+	}
+	return ""
+}
+
+// IsToxic reports whether mail to domain is known to draw complaints or
+// land on blocklists
+func (lc *listClassifier) IsToxic(domain string) bool {
+	return matchDomain(lc.toxic, domain)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Provider treating every example.org address as disposable and every MX
+// under mx.test as the "acme" platform, deferring to the lists otherwise
+type testClassifier struct {
+	ClassificationProvider
+}
+
+func (testClassifier) IsDisposable(domain string) bool { return domain == "example.org" }
+
+func (c testClassifier) Platform(mxHost string) string {
+	if strings.HasSuffix(mxHost, ".mx.test") {
+		return "acme"
+	}
+	return c.ClassificationProvider.Platform(mxHost)
+}
+
+func TestCustomClassificationProvider(t *testing.T) {
+	old := classifier
+	classifier = testClassifier{old}
+	t.Cleanup(func() { classifier = old })
+
+	res := shallowResult("jane@example.org", "in1.mx.test")
+	if res["is_disposable"] != true {
+		t.Errorf("is_disposable = %v, want true from the custom provider", res["is_disposable"])
+	}
+	if res["platform"] != "acme" {
+		t.Errorf("platform = %v, want acme from the custom provider", res["platform"])
+	}
+	res = shallowResult("admin@gmail.com", "gmail-smtp-in.l.google.com")
+	if res["is_disposable"] != false || res["is_role_account"] != true || res["is_free_provider"] != true {
+		t.Errorf("undelegated lookups changed: %v", res)
+	}
+}
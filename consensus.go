@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ask another MX host of the domain about a recipient the first one
+// accepted, in the background, and return a func that waits for the answer
+// and records it as consensus on a result body. Backup MXes often accept
+// anything and bounce later, so one host's 250 can mislead; when the two
+// disagree the verdict becomes unknown. Does nothing with a single MX.
+func consensusSignal(ctx context.Context, hosts []string, answered, rcpt string, timeouts smtpTimeouts, first smtpResult) func(body gin.H) {
+	var others []string
+	for _, h := range hosts {
+		if h != answered {
+			others = append(others, h)
+		}
+	}
+	if len(others) == 0 {
+		return func(gin.H) {}
+	}
+	done := make(chan struct{})
+	var host string
+	var second smtpResult
+	go func() {
+		var results []smtpResult
+		host, results = smtpCheckHosts(ctx, others, timeouts, rcpt)
+		second = results[0]
+		close(done)
+	}()
+	return func(body gin.H) {
+		<-done
+		code := rcptCode(second)
+		c := gin.H{"mx_host": host, "code": code, "agrees": nil}
+		body["consensus"] = c
+		if code == 0 {
+			return // no answer is no second opinion
+		}
+		agrees := replyClass(code) == replyClass(rcptCode(first))
+		c["agrees"] = agrees
+		if !agrees {
+			body["reason"] = "mx_disagreement"
+			body["reply_class"] = "unknown"
+			body["isDeliverable"] = false
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pick the email column: a header mentioning "email"/"mail", otherwise the
+// column whose values most often contain an "@"
+func detectEmailColumn(header []string, rows [][]string) int {
+	for i, name := range header {
+		n := strings.ToLower(name)
+		if strings.Contains(n, "email") || strings.Contains(n, "e-mail") {
+			return i
+		}
+	}
+	for i, name := range header {
+		if strings.Contains(strings.ToLower(name), "mail") {
+			return i
+		}
+	}
+
+	best, bestHits := -1, 0
+	for i := range header {
+		hits := 0
+		for _, row := range rows {
+			if i < len(row) && strings.Contains(row[i], "@") {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+	return best
+}
+
+// Short machine-friendly explanation of a result body
+func resultReason(res gin.H) string {
+	if code, _, failed := resultError(res); failed {
+		return code
+	}
+	if r, ok := res["reason"].(string); ok {
+		return r
+	}
+	s, _ := res["status"].(string)
+	return s
+}
+
+// Status column of exported results
+func resultStatus(res gin.H) string {
+	if _, failed := res["error"]; failed {
+		return "error"
+	}
+	s, _ := res["status"].(string)
+	return s
+}
+
+// POST /email-check/csv (multipart field "file")
+func csvCheckHandler(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"error": apiError("missing_file", "Missing CSV file in field \"file\"")})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"error": apiError("unreadable_upload", "Unreadable upload")})
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = 0 // every row must have as many fields as the header
+	header, err := r.Read()
+	if err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_csv", "Invalid CSV")})
+		return
+	}
+	var rows [][]string
+	ragged := make(map[int]gin.H) // row index -> error result, not checked
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) && perr.Err == csv.ErrFieldCount {
+			ragged[len(rows)] = gin.H{"error": apiError("wrong_field_count",
+				fmt.Sprintf("Line %d has %d fields, the header %d", perr.Line, len(row), len(header)))}
+		} else if err != nil {
+			c.JSON(400, gin.H{"error": apiError("invalid_csv", fmt.Sprintf("Invalid CSV: %v", err))})
+			return
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		c.JSON(400, gin.H{"error": apiError("empty_csv", "CSV has no rows")})
+		return
+	}
+	if len(rows) > maxBulkEmails {
+		c.JSON(400, gin.H{"error": apiError("too_many_rows", "Too many rows, use /jobs for large lists"), "max": maxBulkEmails})
+		return
+	}
+
+	col := detectEmailColumn(header, rows)
+	if col < 0 {
+		c.JSON(400, gin.H{"error": apiError("no_email_column", "No email column found")})
+		return
+	}
+
+	var emails []string
+	var checked []int // row of each email
+	for i, row := range rows {
+		if ragged[i] == nil {
+			emails = append(emails, row[col])
+			checked = append(checked, i)
+		}
+	}
+	if !chargeQuota(c, len(emails)) {
+		return
+	}
+	results := make([]gin.H, len(rows))
+	for i, res := range checkBulk(c.Request.Context(), emails, nil) {
+		results[checked[i]] = res
+	}
+	for i, res := range ragged {
+		results[i] = res
+	}
+
+	name := strings.TrimSuffix(fh.Filename, ".csv") + "-verified.csv"
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Header("Content-Type", "text/csv")
+	c.Status(200)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(append(header, "status", "score", "reason"))
+	for i, row := range rows {
+		res := results[i]
+		// Ragged rows are fitted to the header so the annotations line up
+		row = row[:min(len(row), len(header))]
+		for len(row) < len(header) {
+			row = append(row, "")
+		}
+		w.Write(append(row, resultStatus(res), strconv.Itoa(resultScore(res)), resultReason(res)))
+	}
+	w.Flush()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rows wider or narrower than the header are reported, not checked, and
+// every output row keeps its annotations under the right columns
+func TestCSVRaggedRowsReported(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "list.csv")
+	fw.Write([]byte("name,email\nann,not-an-email\nbob,bob@example.com,extra\ncat\n"))
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/email-check/csv", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	csvCheckHandler(c)
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("output not rectangular: %v", err)
+	}
+	want := [][]string{
+		{"name", "email", "status", "score", "reason"},
+		{"ann", "not-an-email", "error", "0", "invalid_email"},
+		{"bob", "bob@example.com", "error", "0", "wrong_field_count"},
+		{"cat", "", "error", "0", "wrong_field_count"},
+	}
+	for i := range want {
+		if i >= len(rows) || !slices.Equal(rows[i], want[i]) {
+			t.Errorf("rows = %q, want %q", rows, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Deadline of a single raw DNS exchange
+const dnsTimeout = 5 * time.Second
+
+var tlsaType = dnsmessage.Type(52)
+
+// One TLSA record (RFC 6698)
+type tlsaRecord struct {
+	usage        uint8 // 0 PKIX-TA, 1 PKIX-EE, 2 DANE-TA, 3 DANE-EE
+	selector     uint8 // 0 full certificate, 1 SubjectPublicKeyInfo
+	matchingType uint8 // 0 exact, 1 SHA-256, 2 SHA-512
+	data         []byte
+}
+
+// First nameserver of /etc/resolv.conf. The stdlib resolver can't query
+// TLSA, so those lookups go to it directly.
+func systemResolver() string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return "127.0.0.1:53"
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 1 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return "127.0.0.1:53"
+}
+
+// Send one query with the DO bit set, over UDP and again over TCP when the
+// answer comes back truncated
+func dnsExchange(name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	var id [2]byte
+	rand.Read(id[:])
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: binary.BigEndian.Uint16(id[:]), RecursionDesired: true, AuthenticData: true})
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET})
+	b.StartAdditionals()
+	var opt dnsmessage.ResourceHeader
+	opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true)
+	b.OPTResource(opt, dnsmessage.OPTResource{})
+	query, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	server := systemResolver()
+	conn, err := net.DialTimeout("udp", server, dnsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dnsTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf[:n]); err != nil {
+		return nil, err
+	}
+	if msg.ID != binary.BigEndian.Uint16(id[:]) {
+		return nil, errors.New("DNS reply ID mismatch")
+	}
+	if !msg.Truncated {
+		return &msg, nil
+	}
+
+	tcp, err := net.DialTimeout("tcp", server, dnsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tcp.Close()
+	tcp.SetDeadline(time.Now().Add(dnsTimeout))
+	if _, err := tcp.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(query))), query...)); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(tcp, size[:]); err != nil {
+		return nil, err
+	}
+	buf = make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(tcp, buf); err != nil {
+		return nil, err
+	}
+	if err := msg.Unpack(buf); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// TLSA records of the SMTP service on host, and whether the resolver
+// vouched for them with DNSSEC (the AD bit)
+func lookupTLSA(host string) ([]tlsaRecord, bool, error) {
+	msg, err := dnsExchange("_25._tcp."+host, tlsaType)
+	if err != nil {
+		return nil, false, err
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return nil, false, errors.New("TLSA lookup failed: " + msg.RCode.String())
+	}
+	var records []tlsaRecord
+	for _, rr := range msg.Answers {
+		raw, ok := rr.Body.(*dnsmessage.UnknownResource)
+		if rr.Header.Type != tlsaType || !ok || len(raw.Data) < 4 {
+			continue
+		}
+		records = append(records, tlsaRecord{raw.Data[0], raw.Data[1], raw.Data[2], raw.Data[3:]})
+	}
+	return records, msg.AuthenticData, nil
+}
+
+// Compare a certificate against one TLSA record
+func (r tlsaRecord) matches(cert *x509.Certificate) bool {
+	var content []byte
+	switch r.selector {
+	case 0:
+		content = cert.Raw
+	case 1:
+		content = cert.RawSubjectPublicKeyInfo
+	default:
+		return false
+	}
+	switch r.matchingType {
+	case 0:
+		return bytes.Equal(content, r.data)
+	case 1:
+		sum := sha256.Sum256(content)
+		return bytes.Equal(sum[:], r.data)
+	case 2:
+		sum := sha512.Sum512(content)
+		return bytes.Equal(sum[:], r.data)
+	}
+	return false
+}
+
+// Check the STARTTLS chain against the TLSA records. End-entity usages match
+// the leaf, trust-anchor usages any certificate above it; PKIX usages also
+// require the chain to verify against the system roots.
+func daneMatches(records []tlsaRecord, host string, chain []*x509.Certificate) bool {
+	if len(chain) == 0 {
+		return false
+	}
+	pkixOK := func() bool {
+		inter := x509.NewCertPool()
+		for _, c := range chain[1:] {
+			inter.AddCert(c)
+		}
+		_, err := chain[0].Verify(x509.VerifyOptions{DNSName: host, Intermediates: inter})
+		return err == nil
+	}
+	for _, r := range records {
+		var candidates []*x509.Certificate
+		switch r.usage {
+		case 1, 3:
+			candidates = chain[:1]
+		case 0, 2:
+			candidates = chain[1:]
+		}
+		for _, c := range candidates {
+			if r.matches(c) && (r.usage >= 2 || pkixOK()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Look up the MX host's TLSA records in the background and return a func
+// that judges a probe's STARTTLS certificate against them and sets dane to
+// valid, invalid or absent (nil when the lookup failed). Records not signed
+// with DNSSEC are unusable under RFC 7672 and count as absent.
+func daneSignal(mxHost string) func(body gin.H, res smtpResult) {
+	done := make(chan struct{})
+	var records []tlsaRecord
+	var secure bool
+	var err error
+	go func() {
+		defer close(done)
+		records, secure, err = lookupTLSA(mxHost)
+	}()
+	return func(body gin.H, res smtpResult) {
+		<-done
+		switch {
+		case err != nil:
+			body["dane"] = nil
+		case len(records) == 0 || !secure:
+			body["dane"] = "absent"
+		case daneMatches(records, mxHost, res.peerCerts):
+			body["dane"] = "valid"
+		default:
+			body["dane"] = "invalid"
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How far a check goes. Each depth includes the ones before it.
+const (
+	depthSyntax = "syntax" // parse the address only
+	depthDNS    = "dns"    // plus the MX lookup
+	depthSMTP   = "smtp"   // plus RCPT TO for the address itself
+	depthDeep   = "deep"   // plus catch-all, auth records, DANE, blacklists and avatars
+)
+
+// Report whether depth names a known depth; empty means deep
+func validDepth(depth string) bool {
+	switch depth {
+	case "", depthSyntax, depthDNS, depthSMTP, depthDeep:
+		return true
+	}
+	return false
+}
+
+// Settings of one verification
+type checkOptions struct {
+	depth     string
+	timeouts  smtpTimeouts
+	consensus bool         // ask a second MX host about accepted recipients
+	proxy     string       // SMTP proxy to use, "" for the next in turn
+	identity  smtpIdentity // sender and EHLO name overrides
+}
+
+// Full-depth check with the configured timeouts
+func defaultCheckOptions() checkOptions {
+	return checkOptions{depth: depthDeep, timeouts: defaultSMTPTimeouts}
+}
+
+// Set the disposable, role-account, free-provider and toxic flags of an
+// address; mxHost may be empty when no MX was looked up
+func classifyAddress(body gin.H, email, mxHost string) {
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+	body["is_disposable"] = classifier.IsDisposable(domain)
+	role, _, _ := strings.Cut(local, "+") // info+jobs@ is still info@
+	body["is_role_account"] = classifier.IsRole(role)
+	body["is_free_provider"] = classifier.IsFreeProvider(domain) || mxHost != "" && classifier.IsFreeProviderMX(mxHost)
+	body["is_toxic"] = classifier.IsToxic(domain)
+}
+
+// Body of a check that stopped before SMTP, at syntax or dns depth. The
+// mailbox was never asked about, so deliverability stays unknown.
+func shallowResult(email, mxHost string) gin.H {
+	body := gin.H{
+		"status":        "Valid syntax",
+		"reason":        "syntax_valid",
+		"reply_class":   "unknown",
+		"catch_all":     nil,
+		"isDeliverable": false,
+		"risky":         false,
+	}
+	if mxHost != "" {
+		body["status"] = "MX found"
+		body["reason"] = "mx_found"
+		body["mx_host"] = mxHost
+		body["platform"] = classifier.Platform(mxHost)
+	}
+	classifyAddress(body, email, mxHost)
+	return body
+}
+
+// Tag a result body with the depth it was checked at
+func withDepth(body gin.H, depth string) gin.H {
+	body["depth"] = depth
+	return body
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Blacklists the MX addresses are checked against; DNSBL_ZONES overrides
+var dnsblZones = []string{
+	"zen.spamhaus.org", "bl.spamcop.net", "b.barracudacentral.org", "dnsbl.sorbs.net",
+}
+
+// An MX listed on this many blacklists no longer counts as safely deliverable
+const dnsblRiskyListings = 2
+
+// Reverse an address into its DNSBL query prefix: octets for IPv4,
+// nibbles for IPv6
+func dnsblName(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return net.IPv4(v4[3], v4[2], v4[1], v4[0]).String()
+	}
+	const hex = "0123456789abcdef"
+	var parts []string
+	for i := len(ip) - 1; i >= 0; i-- {
+		parts = append(parts, string(hex[ip[i]&0xf]), string(hex[ip[i]>>4]))
+	}
+	return strings.Join(parts, ".")
+}
+
+// Whether zone lists ip. Answers outside 127.0.0.0/8, and Spamhaus'
+// 127.255.255.x error codes for refused resolvers, don't count.
+func dnsblListed(ctx context.Context, ip net.IP, zone string) bool {
+	addrs, err := lookupHost(ctx, dnsblName(ip)+"."+zone)
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if strings.HasPrefix(a, "127.") && !strings.HasPrefix(a, "127.255.255.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Zones listing any address of host, in dnsblZones order
+func lookupBlacklists(ctx context.Context, host string) []string {
+	listed := []string{}
+	addrs, err := lookupHost(ctx, host)
+	if err != nil || len(dnsblZones) == 0 {
+		return listed
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = net.ParseIP(a)
+	}
+	hits := make([]bool, len(dnsblZones))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, zone := range dnsblZones {
+		for _, ip := range ips {
+			wg.Add(1)
+			go func(i int, zone string, ip net.IP) {
+				defer wg.Done()
+				if dnsblListed(ctx, ip, zone) {
+					mu.Lock()
+					hits[i] = true
+					mu.Unlock()
+				}
+			}(i, zone, ip)
+		}
+	}
+	wg.Wait()
+	for i, zone := range dnsblZones {
+		if hits[i] {
+			listed = append(listed, zone)
+		}
+	}
+	return listed
+}
+
+// Check the MX against the blacklists in the background and return a func
+// that adds blacklisted_on to a result body. A deliverable address behind an
+// MX on several lists is marked risky.
+func blacklistSignal(ctx context.Context, mxHost string) func(body gin.H) {
+	done := make(chan struct{})
+	var listed []string
+	go func() {
+		defer close(done)
+		listed = lookupBlacklists(ctx, mxHost)
+	}()
+	return func(body gin.H) {
+		<-done
+		body["blacklisted_on"] = listed
+		if ok, _ := body["isDeliverable"].(bool); ok && len(listed) >= dnsblRiskyListings {
+			body["risky"] = true
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MX, A/AAAA, NS and TXT answers are reused for their TTL unless DNS_CACHE=off,
+// in which case every lookup goes through the stdlib resolver
+var dnsCacheEnabled = true
+
+// Longest any answer is reused, whatever its TTL (DNS_CACHE_MAX_TTL)
+var dnsCacheMaxTTL = time.Hour
+
+// Negative answers without an SOA to take their TTL from
+const dnsNegativeTTL = time.Minute
+
+// Cached answers before expired ones are swept out
+const dnsCacheMaxEntries = 100000
+
+// One question's answer. done is closed once msg/err are set; until then
+// other askers wait for the query in flight instead of sending their own.
+type dnsCacheEntry struct {
+	done    chan struct{}
+	msg     *dnsmessage.Message
+	err     error
+	expires time.Time
+}
+
+var dnsCache = struct {
+	sync.Mutex
+	entries map[string]*dnsCacheEntry
+}{entries: make(map[string]*dnsCacheEntry)}
+
+// How long an answer may be reused: the smallest TTL among its records, or
+// for NXDOMAIN and empty answers the SOA's negative TTL (RFC 2308). 0 for
+// answers not worth keeping, such as SERVFAIL.
+func dnsAnswerTTL(msg *dnsmessage.Message, qtype dnsmessage.Type) time.Duration {
+	ttl := dnsCacheMaxTTL
+	found := false
+	for _, rr := range msg.Answers {
+		if rr.Header.Type == qtype || rr.Header.Type == dnsmessage.TypeCNAME {
+			found = true
+			ttl = min(ttl, time.Duration(rr.Header.TTL)*time.Second)
+		}
+	}
+	if found && msg.RCode == dnsmessage.RCodeSuccess {
+		return ttl
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess && msg.RCode != dnsmessage.RCodeNameError {
+		return 0
+	}
+	for _, rr := range msg.Authorities {
+		if soa, ok := rr.Body.(*dnsmessage.SOAResource); ok {
+			neg := min(time.Duration(rr.Header.TTL), time.Duration(soa.MinTTL)) * time.Second
+			return min(neg, dnsCacheMaxTTL)
+		}
+	}
+	return min(dnsNegativeTTL, dnsCacheMaxTTL)
+}
+
+// Ask the system resolver about name, answering from the cache while the
+// previous answer's TTL lasts. Only the caller's wait is bound to ctx; the
+// query itself finishes for whoever asks next.
+func resolveCached(ctx context.Context, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	key := qtype.String() + " " + strings.ToLower(strings.TrimSuffix(name, "."))
+	now := time.Now()
+	dnsCache.Lock()
+	e, ok := dnsCache.entries[key]
+	if !ok || isClosed(e.done) && now.After(e.expires) {
+		if len(dnsCache.entries) >= dnsCacheMaxEntries {
+			for k, old := range dnsCache.entries {
+				if isClosed(old.done) && now.After(old.expires) {
+					delete(dnsCache.entries, k)
+				}
+			}
+		}
+		e = &dnsCacheEntry{done: make(chan struct{})}
+		dnsCache.entries[key] = e
+		go func() {
+			e.msg, e.err = dnsExchange(name, qtype)
+			if e.err == nil {
+				e.expires = time.Now().Add(dnsAnswerTTL(e.msg, qtype))
+			}
+			close(e.done) // failed exchanges expire at once
+		}()
+	}
+	dnsCache.Unlock()
+
+	select {
+	case <-e.done:
+		return e.msg, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Records of qtype in a cached answer, or the *net.DNSError the stdlib
+// resolver would have returned for it
+func cachedRecords(ctx context.Context, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	msg, err := resolveCached(ctx, name, qtype)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, &net.DNSError{Err: err.Error(), Name: name, IsTimeout: isTimeout(err), IsTemporary: true}
+	}
+	switch msg.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	default:
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	var out []dnsmessage.Resource
+	for _, rr := range msg.Answers {
+		if rr.Header.Type == qtype {
+			out = append(out, rr)
+		}
+	}
+	if len(out) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return out, nil
+}
+
+// MX records of domain, most preferred first, like net.LookupMX
+func lookupMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	ctx, span := tracer.Start(ctx, "dns MX", trace.WithAttributes(attribute.String("dns.question.name", domain)))
+	records, err := resolveMX(ctx, domain)
+	if err != nil && ctx.Err() == nil {
+		recordDNSError("MX", err)
+	}
+	endSpan(span, err)
+	return records, err
+}
+
+func resolveMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	if !dnsCacheEnabled {
+		return net.DefaultResolver.LookupMX(ctx, domain)
+	}
+	rrs, err := cachedRecords(ctx, domain, dnsmessage.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*net.MX, 0, len(rrs))
+	for _, rr := range rrs {
+		mx := rr.Body.(*dnsmessage.MXResource)
+		out = append(out, &net.MX{Host: mx.MX.String(), Pref: mx.Pref})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Pref < out[j].Pref })
+	return out, nil
+}
+
+// IPv4 and IPv6 addresses of host, like net.LookupHost
+func lookupHost(ctx context.Context, host string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "dns A", trace.WithAttributes(attribute.String("dns.question.name", host)))
+	addrs, err := resolveHost(ctx, host)
+	if err != nil && ctx.Err() == nil {
+		recordDNSError("A", err)
+	}
+	endSpan(span, err)
+	return addrs, err
+}
+
+func resolveHost(ctx context.Context, host string) ([]string, error) {
+	if !dnsCacheEnabled {
+		return net.DefaultResolver.LookupHost(ctx, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+	var addrs []string
+	var firstErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		rrs, err := cachedRecords(ctx, host, qtype)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, rr := range rrs {
+			switch body := rr.Body.(type) {
+			case *dnsmessage.AResource:
+				addrs = append(addrs, net.IP(body.A[:]).String())
+			case *dnsmessage.AAAAResource:
+				addrs = append(addrs, net.IP(body.AAAA[:]).String())
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, firstErr
+	}
+	return addrs, nil
+}
+
+// Name servers of domain, like net.LookupNS
+func lookupNS(ctx context.Context, domain string) ([]*net.NS, error) {
+	ctx, span := tracer.Start(ctx, "dns NS", trace.WithAttributes(attribute.String("dns.question.name", domain)))
+	servers, err := resolveNS(ctx, domain)
+	if err != nil && ctx.Err() == nil {
+		recordDNSError("NS", err)
+	}
+	endSpan(span, err)
+	return servers, err
+}
+
+func resolveNS(ctx context.Context, domain string) ([]*net.NS, error) {
+	if !dnsCacheEnabled {
+		return net.DefaultResolver.LookupNS(ctx, domain)
+	}
+	rrs, err := cachedRecords(ctx, domain, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*net.NS, len(rrs))
+	for i, rr := range rrs {
+		out[i] = &net.NS{Host: rr.Body.(*dnsmessage.NSResource).NS.String()}
+	}
+	return out, nil
+}
+
+// TXT records at name, each one's strings joined, like net.LookupTXT
+func lookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "dns TXT", trace.WithAttributes(attribute.String("dns.question.name", name)))
+	txts, err := resolveTXT(ctx, name)
+	if err != nil && ctx.Err() == nil {
+		recordDNSError("TXT", err)
+	}
+	endSpan(span, err)
+	return txts, err
+}
+
+func resolveTXT(ctx context.Context, name string) ([]string, error) {
+	if !dnsCacheEnabled {
+		return net.DefaultResolver.LookupTXT(ctx, name)
+	}
+	rrs, err := cachedRecords(ctx, name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(rrs))
+	for i, rr := range rrs {
+		out[i] = strings.Join(rr.Body.(*dnsmessage.TXTResource).TXT, "")
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// First TXT record at name starting with prefix (case-insensitive)
+func lookupTXTPrefix(ctx context.Context, name, prefix string) (string, bool) {
+	txts, err := lookupTXT(ctx, name)
+	if err != nil {
+		return "", false
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(strings.ToLower(txt), strings.ToLower(prefix)) {
+			return txt, true
+		}
+	}
+	return "", false
+}
+
+// Qualify a domain without checking any real mailbox
+func checkDomain(ctx context.Context, domain string) gin.H {
+	res := gin.H{
+		"domain":           domain,
+		"is_disposable":    classifier.IsDisposable(domain),
+		"is_free_provider": classifier.IsFreeProvider(domain),
+	}
+	_, res["has_spf"] = lookupTXTPrefix(ctx, domain, "v=spf1")
+	_, res["has_dmarc"] = lookupTXTPrefix(ctx, "_dmarc."+domain, "v=DMARC1")
+
+	records, err := lookupMX(ctx, domain)
+	res["implicit_mx"] = false
+	if err != nil || len(records) == 0 {
+		// Mail still goes to the domain's own A/AAAA record
+		hosts, err := lookupMXHosts(ctx, domain)
+		if err != nil {
+			_, res["error"] = mxLookupFailure(domain, err)
+			return res
+		}
+		records = []*net.MX{{Host: hosts[0]}}
+		res["implicit_mx"] = true
+	}
+	mx := make([]gin.H, len(records))
+	for i, r := range records {
+		mx[i] = gin.H{"host": strings.TrimSuffix(r.Host, "."), "priority": r.Pref}
+	}
+	res["mx_records"] = mx
+
+	// RFC 7505: a lone "." MX means the domain accepts no mail
+	nullMX := len(records) == 1 && records[0].Host == "."
+	res["null_mx"] = nullMX
+	if nullMX {
+		return res
+	}
+
+	mxHost := strings.TrimSuffix(records[0].Host, ".")
+	res["mx_host"] = mxHost
+	hosts := make([]string, len(records))
+	for i, r := range records {
+		hosts[i] = r.Host
+	}
+	res["is_parked"] = isParked(ctx, domain, hosts)
+	res["platform"] = classifier.Platform(mxHost)
+	if classifier.IsFreeProviderMX(mxHost) {
+		res["is_free_provider"] = true
+	}
+
+	// Catch-all is unknown unless the fake recipient got an answer, or
+	// when no worker is free to ask
+	release, err := workers.acquire(ctx)
+	if err != nil {
+		res["catch_all"] = nil
+		return res
+	}
+	defer release()
+	fake := smtpCheck(ctx, mxHost, catchAllProbeAddress(domain))
+	res["mx_accepts_connections"] = fake.connected
+	if code := rcptCode(fake); code != 0 {
+		res["catch_all"] = code == 250
+	} else {
+		res["catch_all"] = nil
+	}
+	return res
+}
+
+// POST /domain-check {"domain": "example.com"}
+func domainCheckHandler(c *gin.Context) {
+	var body struct {
+		Domain string `json:"domain"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(body.Domain)), "."))
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	res := checkDomain(c.Request.Context(), domain)
+	stage := "smtp"
+	if _, ok := res["mx_records"]; !ok {
+		stage = "dns"
+	}
+	if abandoned(c, gin.H{"stage": stage, "result": res}) {
+		return
+	}
+	c.JSON(200, res)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// Error codes worth retrying unchanged later
+var retryableErrors = map[string]bool{
+	"job_not_finished":    true,
+	"request_in_progress": true,
+	"internal_error":      true,
+	"rate_limited":        true,
+	"canceled":            true,
+	"deadline_exceeded":   true,
+	"dns_failure":         true,
+	"server_busy":         true,
+}
+
+// Machine-readable error envelope, used as the value of "error" in every
+// error response and failed result: {"code", "message", "retryable"}
+func apiError(code, message string) gin.H {
+	return gin.H{"code": code, "message": message, "retryable": retryableErrors[code]}
+}
+
+// Code and message of a failed result body; ok is false when res succeeded
+func resultError(res gin.H) (code, message string, ok bool) {
+	e, ok := res["error"].(gin.H)
+	if !ok {
+		return "", "", false
+	}
+	code, _ = e["code"].(string)
+	message, _ = e["message"].(string)
+	return code, message, true
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportHeader = []string{"email", "status", "score", "reason", "mx_host"}
+
+// One export row per job result
+func exportRow(res gin.H) []string {
+	email, _ := res["email"].(string)
+	mxHost, _ := res["mx_host"].(string)
+	return []string{email, resultStatus(res), strconv.Itoa(resultScore(res)), resultReason(res), mxHost}
+}
+
+func writeCSVExport(w io.Writer, results []gin.H) error {
+	cw := csv.NewWriter(w)
+	cw.Write(exportHeader)
+	for _, res := range results {
+		cw.Write(exportRow(res))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Minimal single-sheet workbook using inline strings, so no shared
+// string table or styles are needed
+var xlsxParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+}
+
+func writeXLSXRow(w io.Writer, cells []string) {
+	io.WriteString(w, "<row>")
+	for _, cell := range cells {
+		io.WriteString(w, `<c t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(w, []byte(cell))
+		io.WriteString(w, "</t></is></c>")
+	}
+	io.WriteString(w, "</row>")
+}
+
+func writeXLSXExport(w io.Writer, results []gin.H) error {
+	zw := zip.NewWriter(w)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		io.WriteString(f, xlsxParts[name])
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeXLSXRow(sheet, exportHeader)
+	for _, res := range results {
+		writeXLSXRow(sheet, exportRow(res))
+	}
+	io.WriteString(sheet, "</sheetData></worksheet>")
+	return zw.Close()
+}
+
+// GET /jobs/:id/export?format=csv|xlsx
+func jobExportHandler(c *gin.Context) {
+	j, ok := requestedJob(c)
+	if !ok {
+		c.JSON(404, gin.H{"error": apiError("job_not_found", "Job not found")})
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(400, gin.H{"error": apiError("invalid_format", "format must be csv or xlsx")})
+		return
+	}
+
+	j.mu.Lock()
+	done := j.status == "done"
+	results := j.results
+	j.mu.Unlock()
+	if !done {
+		c.JSON(409, gin.H{"error": apiError("job_not_finished", "Job not finished")})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "job-"+j.id+"."+format))
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(200)
+		writeXLSXExport(c.Writer, results)
+		return
+	}
+	c.Header("Content-Type", "text/csv")
+	c.Status(200)
+	writeCSVExport(c.Writer, results)
+}
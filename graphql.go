@@ -0,0 +1,581 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	verifyEmail(email: String!): EmailResult!
+	verifyDomain(domain: String!): DomainResult!
+	job(id: ID!): Job
+}
+
+type EmailResult {
+	email: String!
+	status: String
+	result: String
+	replyClass: String
+	spamtrapRisk: String
+	domainAgeDays: Int
+	isParked: Boolean
+	lowQualityRule: String
+	depth: String
+	score: Int!
+	mxHost: String
+	smtpAttempts: Int
+	platform: String
+	isDeliverable: Boolean!
+	risky: Boolean!
+	hint: String
+	heuristic: String
+	fromCache: Boolean!
+	cachedAgeSeconds: Int!
+	error: String
+	errorCode: String
+	reason: String
+	catchAll: Boolean
+	suggestion: String
+	syntaxError: String
+	normalizedEmail: String
+	localPart: String
+	domain: String
+	domainAscii: String
+	domainUnicode: String
+	isSubaddressed: Boolean!
+	baseEmail: String
+	subaddressTag: String
+	baseVerified: Boolean!
+	isDisposable: Boolean!
+	isRoleAccount: Boolean!
+	isFreeProvider: Boolean!
+	isToxic: Boolean!
+	hasGravatar: Boolean
+	spf: SpfRecord
+	dmarc: DmarcPolicy
+	dkim: DkimKeys
+	mtaSts: MtaSts
+	dane: String
+	tlsCert: TlsCert
+	blacklistedOn: [String!]!
+	smtp: SmtpSession
+	rcpt: RcptReply
+	vrfy: VrfyReply
+	consensus: Consensus
+}
+
+type SmtpSession {
+	connected: Boolean!
+	port: Int!
+	banner: String!
+	tls: String!
+	tlsError: String
+	proxy: String
+	sourceIp: String
+	addressFamily: String
+	helo: String
+	mailFrom: String
+}
+
+type Consensus {
+	mxHost: String!
+	code: Int!
+	agrees: Boolean
+}
+
+type SpfRecord {
+	exists: Boolean!
+	record: String
+	all: String
+	strict: Boolean!
+	includes: [String!]!
+	ips: [String!]!
+}
+
+type DmarcPolicy {
+	exists: Boolean!
+	record: String
+	policy: String
+	adkim: String
+	aspf: String
+	rua: String
+}
+
+type DkimKeys {
+	selectorsChecked: [String!]!
+	found: [String!]!
+}
+
+type TlsCert {
+	valid: Boolean!
+	trusted: Boolean!
+	hostnameMatch: Boolean!
+	expired: Boolean!
+	selfSigned: Boolean!
+	subject: String!
+	issuer: String!
+	notAfter: String!
+	daysUntilExpiry: Int!
+	error: String
+}
+
+type MtaSts {
+	exists: Boolean!
+	enforced: Boolean!
+	mode: String
+	mx: [String!]!
+	maxAge: Int
+	policyError: String
+}
+
+type RcptReply {
+	code: Int!
+	enhancedCode: String
+	enhancedReason: String
+	message: String!
+}
+
+type VrfyReply {
+	command: String!
+	code: Int!
+	enhancedCode: String
+	message: String!
+	exists: Boolean
+}
+
+type DomainResult {
+	domain: String!
+	mxHost: String
+	platform: String
+	catchAll: Boolean
+	nullMx: Boolean!
+	implicitMx: Boolean!
+	hasSpf: Boolean!
+	hasDmarc: Boolean!
+	mxAcceptsConnections: Boolean!
+	isDisposable: Boolean!
+	isFreeProvider: Boolean!
+	isParked: Boolean
+	error: String
+	errorCode: String
+}
+
+type Job {
+	id: ID!
+	status: String!
+	total: Int!
+	completed: Int!
+	progress: Float!
+	results: [EmailResult]
+}
+`
+
+var gqlSchema = graphql.MustParseSchema(graphqlSchema, &gqlResolver{}, graphql.UseFieldResolvers())
+
+type gqlResolver struct{}
+
+type gqlEmailResult struct {
+	Email            string
+	Status           *string
+	Result           *string
+	ReplyClass       *string
+	SpamtrapRisk     *string
+	DomainAgeDays    *int32
+	IsParked         *bool
+	LowQualityRule   *string
+	Depth            *string
+	Score            int32
+	MxHost           *string
+	SmtpAttempts     *int32
+	Platform         *string
+	IsDeliverable    bool
+	Risky            bool
+	Hint             *string
+	Heuristic        *string
+	FromCache        bool
+	CachedAgeSeconds int32
+	Error            *string
+	ErrorCode        *string
+	Reason           *string
+	CatchAll         *bool
+	Suggestion       *string
+	SyntaxError      *string
+	NormalizedEmail  *string
+	LocalPart        *string
+	Domain           *string
+	DomainAscii      *string
+	DomainUnicode    *string
+	IsSubaddressed   bool
+	BaseEmail        *string
+	SubaddressTag    *string
+	BaseVerified     bool
+	IsDisposable     bool
+	IsRoleAccount    bool
+	IsFreeProvider   bool
+	IsToxic          bool
+	HasGravatar      *bool
+	Spf              *gqlSpfRecord
+	Dmarc            *gqlDmarcPolicy
+	Dkim             *gqlDkimKeys
+	MtaSts           *gqlMtaSts
+	Dane             *string
+	TlsCert          *gqlTlsCert
+	BlacklistedOn    []string
+	Smtp             *gqlSmtpSession
+	Rcpt             *gqlRcptReply
+	Vrfy             *gqlVrfyReply
+	Consensus        *gqlConsensus
+}
+
+type gqlSmtpSession struct {
+	Connected     bool
+	Port          int32
+	Banner        string
+	Tls           string
+	TlsError      *string
+	Proxy         *string
+	SourceIp      *string
+	AddressFamily *string
+	Helo          *string
+	MailFrom      *string
+}
+
+type gqlConsensus struct {
+	MxHost string
+	Code   int32
+	Agrees *bool
+}
+
+type gqlSpfRecord struct {
+	Exists   bool
+	Record   *string
+	All      *string
+	Strict   bool
+	Includes []string
+	Ips      []string
+}
+
+type gqlDmarcPolicy struct {
+	Exists bool
+	Record *string
+	Policy *string
+	Adkim  *string
+	Aspf   *string
+	Rua    *string
+}
+
+type gqlDkimKeys struct {
+	SelectorsChecked []string
+	Found            []string
+}
+
+type gqlTlsCert struct {
+	Valid           bool
+	Trusted         bool
+	HostnameMatch   bool
+	Expired         bool
+	SelfSigned      bool
+	Subject         string
+	Issuer          string
+	NotAfter        string
+	DaysUntilExpiry int32
+	Error           *string
+}
+
+type gqlMtaSts struct {
+	Exists      bool
+	Enforced    bool
+	Mode        *string
+	Mx          []string
+	MaxAge      *int32
+	PolicyError *string
+}
+
+type gqlRcptReply struct {
+	Code           int32
+	EnhancedCode   *string
+	EnhancedReason *string
+	Message        string
+}
+
+type gqlVrfyReply struct {
+	Command      string
+	Code         int32
+	EnhancedCode *string
+	Message      string
+	Exists       *bool
+}
+
+type gqlDomainResult struct {
+	Domain               string
+	MxHost               *string
+	Platform             *string
+	CatchAll             *bool
+	NullMx               bool
+	ImplicitMx           bool
+	HasSpf               bool
+	HasDmarc             bool
+	MxAcceptsConnections bool
+	IsDisposable         bool
+	IsFreeProvider       bool
+	IsParked             *bool
+	Error                *string
+	ErrorCode            *string
+}
+
+type gqlJob struct {
+	ID        graphql.ID
+	Status    string
+	Total     int32
+	Completed int32
+	Progress  float64
+	Results   *[]*gqlEmailResult
+}
+
+// Optional string field of a response body
+func optString(res gin.H, key string) *string {
+	if s, ok := res[key].(string); ok {
+		return &s
+	}
+	return nil
+}
+
+// Code and message of a failed result, nil when it succeeded
+func optError(res gin.H) (*string, *string) {
+	code, msg, failed := resultError(res)
+	if !failed {
+		return nil, nil
+	}
+	return &code, &msg
+}
+
+// Pointer to s, nil when it is empty
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// GraphQL form of a result, built from its v1 body so the two APIs report
+// the same fields the same way
+func toGQLEmailResult(email string, res gin.H) *gqlEmailResult {
+	v := toV1Response(email, res)
+	out := &gqlEmailResult{
+		Email:            email,
+		Status:           nonEmpty(v.Status),
+		Result:           nonEmpty(v.Result),
+		ReplyClass:       nonEmpty(v.ReplyClass),
+		SpamtrapRisk:     nonEmpty(v.SpamtrapRisk),
+		IsParked:         v.IsParked,
+		LowQualityRule:   nonEmpty(v.LowQualityRule),
+		Depth:            nonEmpty(v.Depth),
+		Score:            int32(v.Score),
+		MxHost:           nonEmpty(v.MXHost),
+		Platform:         nonEmpty(v.Platform),
+		IsDeliverable:    v.Deliverable,
+		Risky:            v.Risky,
+		Hint:             nonEmpty(v.Hint),
+		Heuristic:        nonEmpty(v.Heuristic),
+		FromCache:        v.FromCache,
+		CachedAgeSeconds: int32(v.CachedAgeSeconds),
+		Reason:           nonEmpty(v.Reason),
+		Suggestion:       nonEmpty(v.Suggestion),
+		SyntaxError:      nonEmpty(v.SyntaxError),
+		NormalizedEmail:  nonEmpty(v.NormalizedEmail),
+		LocalPart:        nonEmpty(v.LocalPart),
+		Domain:           nonEmpty(v.Domain),
+		DomainAscii:      nonEmpty(v.DomainASCII),
+		DomainUnicode:    nonEmpty(v.DomainUnicode),
+		IsSubaddressed:   v.IsSubaddressed,
+		BaseEmail:        nonEmpty(v.BaseEmail),
+		SubaddressTag:    nonEmpty(v.SubaddressTag),
+		BaseVerified:     v.BaseVerified,
+		IsDisposable:     v.IsDisposable,
+		IsRoleAccount:    v.IsRoleAccount,
+		IsFreeProvider:   v.IsFreeProvider,
+		IsToxic:          v.IsToxic,
+		HasGravatar:      v.HasGravatar,
+		Dane:             nonEmpty(v.DANE),
+		BlacklistedOn:    []string{},
+	}
+	out.ErrorCode, out.Error = optError(res)
+	// v1 reports an undecided catch-all as false; GraphQL can return null
+	if b, ok := res["catch_all"].(bool); ok {
+		out.CatchAll = &b
+	}
+	if v.SMTPAttempts > 0 {
+		attempts := int32(v.SMTPAttempts)
+		out.SmtpAttempts = &attempts
+	}
+	if v.DomainAgeDays != nil {
+		days := int32(*v.DomainAgeDays)
+		out.DomainAgeDays = &days
+	}
+	if v.BlacklistedOn != nil {
+		out.BlacklistedOn = v.BlacklistedOn
+	}
+	if c := v.Consensus; c != nil {
+		out.Consensus = &gqlConsensus{MxHost: c.MXHost, Code: int32(c.Code), Agrees: c.Agrees}
+	}
+	if s := v.SMTP; s != nil {
+		out.Smtp = &gqlSmtpSession{
+			Connected:     s.Connected,
+			Port:          int32(s.Port),
+			Banner:        s.Banner,
+			Tls:           s.TLS,
+			TlsError:      nonEmpty(s.TLSError),
+			Proxy:         nonEmpty(s.Proxy),
+			SourceIp:      nonEmpty(s.SourceIP),
+			AddressFamily: nonEmpty(s.AddressFamily),
+			Helo:          nonEmpty(s.Helo),
+			MailFrom:      nonEmpty(s.MailFrom),
+		}
+	}
+	if r := v.RCPT; r != nil {
+		out.Rcpt = &gqlRcptReply{Code: int32(r.Code), EnhancedCode: nonEmpty(r.EnhancedCode), EnhancedReason: nonEmpty(r.EnhancedReason), Message: r.Message}
+	}
+	if r := v.VRFY; r != nil {
+		out.Vrfy = &gqlVrfyReply{Command: r.Command, Code: int32(r.Code), EnhancedCode: nonEmpty(r.EnhancedCode), Message: r.Message, Exists: r.Exists}
+	}
+	if spf := v.SPF; spf != nil {
+		out.Spf = &gqlSpfRecord{Exists: spf.Exists, Record: nonEmpty(spf.Record), All: nonEmpty(spf.All), Strict: spf.Strict, Includes: []string{}, Ips: []string{}}
+		if spf.Includes != nil {
+			out.Spf.Includes = spf.Includes
+		}
+		if spf.IPs != nil {
+			out.Spf.Ips = spf.IPs
+		}
+	}
+	if d := v.DMARC; d != nil {
+		out.Dmarc = &gqlDmarcPolicy{Exists: d.Exists, Record: nonEmpty(d.Record), Policy: nonEmpty(d.Policy), Adkim: nonEmpty(d.ADKIM), Aspf: nonEmpty(d.ASPF), Rua: nonEmpty(d.RUA)}
+	}
+	if d := v.DKIM; d != nil {
+		out.Dkim = &gqlDkimKeys{SelectorsChecked: []string{}, Found: []string{}}
+		if d.SelectorsChecked != nil {
+			out.Dkim.SelectorsChecked = d.SelectorsChecked
+		}
+		if d.Found != nil {
+			out.Dkim.Found = d.Found
+		}
+	}
+	if sts := v.MTASTS; sts != nil {
+		out.MtaSts = &gqlMtaSts{Exists: sts.Exists, Enforced: sts.Enforced, Mode: nonEmpty(sts.Mode), Mx: []string{}, PolicyError: nonEmpty(sts.PolicyError)}
+		if sts.MX != nil {
+			out.MtaSts.Mx = sts.MX
+		}
+		if sts.MaxAge > 0 {
+			age := int32(sts.MaxAge)
+			out.MtaSts.MaxAge = &age
+		}
+	}
+	if cert := v.TLSCert; cert != nil {
+		out.TlsCert = &gqlTlsCert{
+			Valid:           cert.Valid,
+			Trusted:         cert.Trusted,
+			HostnameMatch:   cert.HostnameMatch,
+			Expired:         cert.Expired,
+			SelfSigned:      cert.SelfSigned,
+			Subject:         cert.Subject,
+			Issuer:          cert.Issuer,
+			DaysUntilExpiry: int32(cert.DaysUntilExpiry),
+			Error:           nonEmpty(cert.Error),
+		}
+		if !cert.NotAfter.IsZero() {
+			out.TlsCert.NotAfter = cert.NotAfter.Format(time.RFC3339)
+		}
+	}
+	return out
+}
+
+func (*gqlResolver) VerifyEmail(ctx context.Context, args struct{ Email string }) *gqlEmailResult {
+	email, ok := cleanEmail(args.Email)
+	if !ok {
+		return toGQLEmailResult(args.Email, gin.H{"error": apiError("invalid_email", "Invalid email")})
+	}
+	if failed := chargeQuotaCtx(ctx, email); failed != nil {
+		return toGQLEmailResult(email, failed)
+	}
+	_, res := cachedCheck(ctx, email, func() (int, gin.H) { return checkEmail(ctx, email, defaultCheckOptions()) })
+	return toGQLEmailResult(email, res)
+}
+
+func (*gqlResolver) VerifyDomain(ctx context.Context, args struct{ Domain string }) *gqlDomainResult {
+	domain := strings.ToLower(strings.TrimSpace(args.Domain))
+	res := checkDomain(ctx, domain)
+	out := &gqlDomainResult{
+		Domain:   domain,
+		MxHost:   optString(res, "mx_host"),
+		Platform: optString(res, "platform"),
+	}
+	out.ErrorCode, out.Error = optError(res)
+	if b, ok := res["catch_all"].(bool); ok {
+		out.CatchAll = &b
+	}
+	out.NullMx, _ = res["null_mx"].(bool)
+	out.ImplicitMx, _ = res["implicit_mx"].(bool)
+	out.HasSpf, _ = res["has_spf"].(bool)
+	out.HasDmarc, _ = res["has_dmarc"].(bool)
+	out.MxAcceptsConnections, _ = res["mx_accepts_connections"].(bool)
+	out.IsDisposable, _ = res["is_disposable"].(bool)
+	out.IsFreeProvider, _ = res["is_free_provider"].(bool)
+	if b, ok := res["is_parked"].(bool); ok {
+		out.IsParked = &b
+	}
+	return out
+}
+
+func (*gqlResolver) Job(ctx context.Context, args struct{ ID graphql.ID }) *gqlJob {
+	j, ok := jobs.get(string(args.ID))
+	if !ok || j.apiKey != ctxAPIKeyName(ctx) {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := &gqlJob{
+		ID:        graphql.ID(j.id),
+		Status:    j.status,
+		Total:     int32(len(j.emails)),
+		Completed: int32(j.completed),
+		Progress:  float64(j.completed) / float64(len(j.emails)),
+	}
+	if j.status == "done" {
+		results := make([]*gqlEmailResult, len(j.results))
+		for i, res := range j.results {
+			email, _ := res["email"].(string)
+			results[i] = toGQLEmailResult(email, res)
+		}
+		out.Results = &results
+	}
+	return out
+}
+
+// POST /graphql {"query": "...", "operationName": "...", "variables": {...}}
+func graphqlHandler(c *gin.Context) {
+	var body struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	resp := gqlSchema.Exec(c.Request.Context(), body.Query, body.OperationName, body.Variables)
+	out, err := json.Marshal(resp)
+	if err != nil {
+		c.JSON(500, gin.H{"error": apiError("internal_error", "Encoding response failed")})
+		return
+	}
+	c.Data(200, "application/json", out)
+}
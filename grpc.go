@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"emailhunting/proto/verifierpb"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// gRPC front end for the same checks as the REST API
+type verifierServer struct {
+	verifierpb.UnimplementedVerifierServer
+}
+
+func (verifierServer) Verify(ctx context.Context, req *verifierpb.VerifyRequest) (*verifierpb.VerifyResponse, error) {
+	return verifyRPC(ctx, req), nil
+}
+
+func (verifierServer) VerifyStream(stream verifierpb.Verifier_VerifyStreamServer) error {
+	var sendMu sync.Mutex
+	var sendErr error
+	sem := make(chan struct{}, wsMaxInFlight)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(req *verifierpb.VerifyRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res := verifyRPC(stream.Context(), req)
+			sendMu.Lock()
+			defer sendMu.Unlock()
+			if sendErr == nil {
+				sendErr = stream.Send(res)
+			}
+		}(req)
+	}
+}
+
+// Run one check and convert the response body to its protobuf form
+func verifyRPC(ctx context.Context, req *verifierpb.VerifyRequest) *verifierpb.VerifyResponse {
+	email, ok := cleanEmail(req.GetEmail())
+	if !ok {
+		return &verifierpb.VerifyResponse{Id: req.GetId(), Email: req.GetEmail(), Error: "Invalid email", ErrorCode: "invalid_email"}
+	}
+	if failed := chargeQuotaCtx(ctx, email); failed != nil {
+		return resultToProto(req.GetId(), email, failed)
+	}
+	_, res := cachedCheck(ctx, email, func() (int, gin.H) { return checkEmail(ctx, email, defaultCheckOptions()) })
+	return resultToProto(req.GetId(), email, res)
+}
+
+// Protobuf form of a result, built from its v1 body so the two APIs report
+// the same fields the same way
+func resultToProto(id, email string, res gin.H) *verifierpb.VerifyResponse {
+	v := toV1Response(email, res)
+	out := &verifierpb.VerifyResponse{
+		Id:               id,
+		Email:            email,
+		Status:           v.Status,
+		Result:           v.Result,
+		ReplyClass:       v.ReplyClass,
+		Score:            int32(v.Score),
+		IsDeliverable:    v.Deliverable,
+		Risky:            v.Risky,
+		Depth:            v.Depth,
+		SpamtrapRisk:     v.SpamtrapRisk,
+		IsParked:         v.IsParked,
+		LowQualityRule:   v.LowQualityRule,
+		MxHost:           v.MXHost,
+		SmtpAttempts:     int32(v.SMTPAttempts),
+		Platform:         v.Platform,
+		Hint:             v.Hint,
+		Heuristic:        v.Heuristic,
+		Reason:           v.Reason,
+		SyntaxError:      v.SyntaxError,
+		Suggestion:       v.Suggestion,
+		IsDisposable:     v.IsDisposable,
+		IsRoleAccount:    v.IsRoleAccount,
+		IsFreeProvider:   v.IsFreeProvider,
+		IsToxic:          v.IsToxic,
+		HasGravatar:      v.HasGravatar,
+		Dane:             v.DANE,
+		BlacklistedOn:    v.BlacklistedOn,
+		NormalizedEmail:  v.NormalizedEmail,
+		LocalPart:        v.LocalPart,
+		Domain:           v.Domain,
+		DomainAscii:      v.DomainASCII,
+		DomainUnicode:    v.DomainUnicode,
+		IsSubaddressed:   v.IsSubaddressed,
+		BaseEmail:        v.BaseEmail,
+		SubaddressTag:    v.SubaddressTag,
+		BaseVerified:     v.BaseVerified,
+		FromCache:        v.FromCache,
+		CachedAgeSeconds: int64(v.CachedAgeSeconds),
+	}
+	out.ErrorCode, out.Error, _ = resultError(res)
+	// v1 reports an undecided catch-all as false; protobuf can leave it unset
+	if catchAll, ok := res["catch_all"].(bool); ok {
+		out.CatchAll = &catchAll
+	}
+	if v.DomainAgeDays != nil {
+		days := int32(*v.DomainAgeDays)
+		out.DomainAgeDays = &days
+	}
+	if c := v.Consensus; c != nil {
+		out.Consensus = &verifierpb.Consensus{MxHost: c.MXHost, Code: int32(c.Code), Agrees: c.Agrees}
+	}
+	if s := v.SMTP; s != nil {
+		out.Smtp = &verifierpb.SmtpSession{
+			Connected:     s.Connected,
+			Port:          int32(s.Port),
+			Banner:        s.Banner,
+			Tls:           s.TLS,
+			TlsError:      s.TLSError,
+			Proxy:         s.Proxy,
+			SourceIp:      s.SourceIP,
+			AddressFamily: s.AddressFamily,
+			Helo:          s.Helo,
+			MailFrom:      s.MailFrom,
+		}
+	}
+	if r := v.RCPT; r != nil {
+		out.Rcpt = &verifierpb.RcptReply{Code: int32(r.Code), EnhancedCode: r.EnhancedCode, EnhancedReason: r.EnhancedReason, Message: r.Message}
+	}
+	if r := v.VRFY; r != nil {
+		out.Vrfy = &verifierpb.VrfyReply{Command: r.Command, Code: int32(r.Code), EnhancedCode: r.EnhancedCode, Message: r.Message, Exists: r.Exists}
+	}
+	if spf := v.SPF; spf != nil {
+		out.Spf = &verifierpb.SpfRecord{Exists: spf.Exists, Record: spf.Record, All: spf.All, Strict: spf.Strict, Includes: spf.Includes, Ips: spf.IPs}
+	}
+	if d := v.DMARC; d != nil {
+		out.Dmarc = &verifierpb.DmarcPolicy{Exists: d.Exists, Record: d.Record, Policy: d.Policy, Adkim: d.ADKIM, Aspf: d.ASPF, Rua: d.RUA}
+	}
+	if d := v.DKIM; d != nil {
+		out.Dkim = &verifierpb.DkimKeys{SelectorsChecked: d.SelectorsChecked, Found: d.Found}
+	}
+	if sts := v.MTASTS; sts != nil {
+		out.MtaSts = &verifierpb.MtaSts{Exists: sts.Exists, Enforced: sts.Enforced, Mode: sts.Mode, Mx: sts.MX, MaxAge: int64(sts.MaxAge), PolicyError: sts.PolicyError}
+	}
+	if cert := v.TLSCert; cert != nil {
+		out.TlsCert = &verifierpb.TlsCert{
+			Valid:           cert.Valid,
+			Trusted:         cert.Trusted,
+			HostnameMatch:   cert.HostnameMatch,
+			Expired:         cert.Expired,
+			SelfSigned:      cert.SelfSigned,
+			Subject:         cert.Subject,
+			Issuer:          cert.Issuer,
+			DaysUntilExpiry: int32(cert.DaysUntilExpiry),
+			Error:           cert.Error,
+		}
+		if !cert.NotAfter.IsZero() {
+			out.TlsCert.NotAfter = cert.NotAfter.Format(time.RFC3339)
+		}
+	}
+	return out
+}
+
+// Serve the Verifier service on addr in the background
+func startGRPC(addr string) *grpc.Server {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("gRPC listen failed", "addr", addr, "error", err)
+	}
+	var opts []grpc.ServerOption
+	if len(apiKeys) > 0 {
+		opts = append(opts, grpc.UnaryInterceptor(grpcUnaryAuth), grpc.StreamInterceptor(grpcStreamAuth))
+	}
+	srv := grpc.NewServer(opts...)
+	verifierpb.RegisterVerifierServer(srv, verifierServer{})
+	slog.Info("gRPC listening", "addr", addr)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			fatal("gRPC server stopped", "error", err)
+		}
+	}()
+	return srv
+}
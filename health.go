@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Domain whose MX is used to test DNS and outbound port 25
+const healthProbeDomain = "gmail.com"
+
+// How long a readiness report is reused before probing again
+const readyCacheTTL = 30 * time.Second
+
+var ready struct {
+	mu      sync.Mutex
+	checked time.Time
+	ok      bool
+	report  gin.H
+}
+
+// Probe DNS and outbound SMTP, the two things every check depends on
+func readinessReport() (bool, gin.H) {
+	report := gin.H{}
+	ok := true
+
+	start := time.Now()
+	mxHost, err := lookupMXHost(context.Background(), healthProbeDomain)
+	if err != nil {
+		ok = false
+		report["dns"] = gin.H{"ok": false, "error": err.Error()}
+		report["smtp_port_25"] = gin.H{"ok": false, "error": "skipped, DNS unavailable"}
+	} else {
+		report["dns"] = gin.H{"ok": true, "latency_ms": time.Since(start).Milliseconds()}
+
+		start = time.Now()
+		conn, _, err := dialSMTP(context.Background(), net.JoinHostPort(mxHost, "25"), "", 5*time.Second)
+		if err != nil {
+			ok = false
+			report["smtp_port_25"] = gin.H{"ok": false, "host": mxHost, "error": err.Error()}
+		} else {
+			conn.Close()
+			report["smtp_port_25"] = gin.H{"ok": true, "host": mxHost, "latency_ms": time.Since(start).Milliseconds()}
+		}
+	}
+
+	// A cache that can't be reached only costs re-probing, so it is reported
+	// without failing readiness
+	cache := gin.H{"ok": true, "enabled": verifyCache != nil}
+	if verifyCache != nil {
+		cache["backend"] = resultCacheBackend
+		if err := verifyCache.ping(); err != nil {
+			cache["ok"], cache["error"] = false, err.Error()
+		}
+	}
+	report["cache"] = cache
+	jobs.mu.Lock()
+	report["jobs"] = gin.H{"ok": true, "count": len(jobs.jobs)}
+	jobs.mu.Unlock()
+	return ok, report
+}
+
+// GET /healthz — liveness, never touches the network
+func healthzHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"status": "ok"})
+}
+
+// GET /readyz — readiness, 503 when DNS or outbound port 25 is broken
+func readyzHandler(c *gin.Context) {
+	ready.mu.Lock()
+	if time.Since(ready.checked) > readyCacheTTL {
+		ready.ok, ready.report = readinessReport()
+		ready.checked = time.Now()
+	}
+	ok, report := ready.ok, ready.report
+	ready.mu.Unlock()
+
+	status, code := "ok", 200
+	if !ok {
+		status, code = "unavailable", 503
+	}
+	if draining.Load() {
+		status, code = "draining", 503
+	}
+	c.JSON(code, gin.H{"status": status, "checks": report})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// What we know about how a mail platform answers RCPT TO
+type platformHeuristic struct {
+	acceptsAll   bool   // 2xx for any recipient, bounces later
+	policyBlocks bool   // 5.7.x rejections are about the sender, not the mailbox
+	note         string // reported as heuristic when the verdict is adjusted
+}
+
+// Platforms (see classifier.Platform) whose answers the generic reply-code
+// reading gets wrong. Google and Zoho answer RCPT truthfully and need none.
+var platformHeuristics = map[string]platformHeuristic{
+	"yahoo": {
+		acceptsAll: true,
+		note:       "Yahoo accepts every recipient at RCPT time and bounces unknown ones later",
+	},
+	"microsoft": {
+		policyBlocks: true,
+		note:         "Microsoft 365 rejects blocked senders with 5.7.x before looking at the mailbox",
+	},
+	"proofpoint": {
+		acceptsAll:   true,
+		policyBlocks: true,
+		note:         "Proofpoint gateways usually accept any recipient and reject on sender policy",
+	},
+	"mimecast": {
+		acceptsAll:   true,
+		policyBlocks: true,
+		note:         "Mimecast gateways usually accept any recipient and reject on sender policy",
+	},
+}
+
+// Downgrade a verdict the platform is known not to mean: an acceptance from
+// an accept-everything platform becomes risky with an unknown result, a
+// sender-policy rejection is no longer proof the mailbox doesn't exist
+func applyPlatformHeuristics(body gin.H, platform string, res smtpResult) {
+	h, ok := platformHeuristics[platform]
+	if !ok {
+		return
+	}
+	code, enhanced, _ := parseReply(res.rcpt)
+	switch {
+	case h.acceptsAll && code/100 == 2:
+		body["risky"] = true
+		body["reply_class"] = "unknown"
+		body["reason"] = "accept_all"
+	case h.policyBlocks && code/100 == 5 && strings.HasPrefix(enhanced, "5.7."):
+		body["isDeliverable"] = false
+		body["reply_class"] = "unknown"
+		body["reason"] = "policy_block"
+	default:
+		return
+	}
+	body["heuristic"] = h.note
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Verification history database (HISTORY_DB): "postgres://..." or
+// "sqlite:/path/to/history.db"; empty keeps no history
+var historyDB string
+
+// Records waiting to be written; beyond this they are dropped rather than
+// slowing responses down
+const historyQueueSize = 10000
+
+// Page size limits of the history endpoints
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// The same SQL runs on Postgres and SQLite, so timestamps are unix seconds
+var historySchema = []string{
+	`CREATE TABLE IF NOT EXISTS verifications (
+		email_hash TEXT NOT NULL,
+		domain     TEXT NOT NULL,
+		result     TEXT NOT NULL,
+		reason     TEXT NOT NULL,
+		score      INTEGER NOT NULL,
+		mx_host    TEXT NOT NULL,
+		cached     BOOLEAN NOT NULL,
+		checked_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS verifications_email ON verifications (email_hash, checked_at)`,
+	`CREATE INDEX IF NOT EXISTS verifications_domain ON verifications (domain, checked_at)`,
+	`CREATE INDEX IF NOT EXISTS verifications_checked ON verifications (checked_at)`,
+}
+
+// One served verification
+type historyRecord struct {
+	emailHash string
+	domain    string
+	result    string
+	reason    string
+	score     int
+	mxHost    string
+	cached    bool
+	checkedAt time.Time
+}
+
+// Verification history, written in the background
+type historyStore struct {
+	db      *sql.DB
+	records chan historyRecord
+	written chan struct{} // closed once the queue is drained after close
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// History store; nil when HISTORY_DB is unset
+var history *historyStore
+
+func openHistory(dsn string) (*historyStore, error) {
+	driver, source := "pgx", dsn
+	if path, ok := strings.CutPrefix(dsn, "sqlite:"); ok {
+		driver, source = "sqlite", path
+	} else if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return nil, fmt.Errorf("want a postgres:// URL or sqlite:<path>")
+	}
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		db.SetMaxOpenConns(1) // SQLite takes one writer at a time anyway
+	}
+	for _, stmt := range historySchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	h := &historyStore{db: db, records: make(chan historyRecord, historyQueueSize), written: make(chan struct{})}
+	go h.writeLoop()
+	return h, nil
+}
+
+// Hex SHA-256 of a normalized address; history never stores the address
+func emailHash(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:])
+}
+
+// Queue a served result for writing
+func (h *historyStore) record(email string, res gin.H) {
+	rec := historyRecord{
+		emailHash: emailHash(email),
+		domain:    email[strings.LastIndex(email, "@")+1:],
+		checkedAt: time.Now(),
+	}
+	rec.result, _ = res["result"].(string)
+	rec.reason, _ = res["reason"].(string)
+	if code, _, failed := resultError(res); failed {
+		rec.reason = code
+	}
+	rec.score, _ = res["score"].(int)
+	rec.mxHost, _ = res["mx_host"].(string)
+	rec.cached, _ = res["from_cache"].(bool)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.records <- rec:
+	default:
+		slog.Warn("history queue full, dropping a record")
+	}
+}
+
+// Stop taking records and wait for the queued ones to be written
+func (h *historyStore) close() {
+	h.mu.Lock()
+	h.closed = true
+	close(h.records)
+	h.mu.Unlock()
+	select {
+	case <-h.written:
+	case <-time.After(10 * time.Second):
+		slog.Warn("history flush timed out", "records_left", len(h.records))
+	}
+	h.db.Close()
+}
+
+// Write queued records in batches, one transaction per batch
+func (h *historyStore) writeLoop() {
+	defer close(h.written)
+	for rec := range h.records {
+		batch := []historyRecord{rec}
+	fill:
+		for len(batch) < 500 {
+			select {
+			case rec, ok := <-h.records:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, rec)
+			default:
+				break fill
+			}
+		}
+		if err := h.insert(batch); err != nil {
+			slog.Error("history write failed", "records", len(batch), "error", err)
+		}
+	}
+}
+
+func (h *historyStore) insert(batch []historyRecord) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO verifications
+		(email_hash, domain, result, reason, score, mx_host, cached, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range batch {
+		if _, err := stmt.Exec(r.emailHash, r.domain, r.result, r.reason, r.score, r.mxHost, r.cached, r.checkedAt.Unix()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Newest verifications matching column = value
+func (h *historyStore) recent(column, value string, limit int) ([]gin.H, error) {
+	rows, err := h.db.Query(`SELECT domain, result, reason, score, mx_host, cached, checked_at
+		FROM verifications WHERE `+column+` = $1 ORDER BY checked_at DESC LIMIT $2`, value, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []gin.H{}
+	for rows.Next() {
+		var domain, result, reason, mxHost string
+		var score int
+		var cached bool
+		var at int64
+		if err := rows.Scan(&domain, &result, &reason, &score, &mxHost, &cached, &at); err != nil {
+			return nil, err
+		}
+		out = append(out, gin.H{
+			"domain":     domain,
+			"result":     result,
+			"reason":     reason,
+			"score":      score,
+			"mx_host":    mxHost,
+			"from_cache": cached,
+			"checked_at": time.Unix(at, 0).UTC(),
+		})
+	}
+	return out, rows.Err()
+}
+
+// Verifications per UTC day and result over the last days, optionally at
+// one domain
+func (h *historyStore) trends(days int, domain string) ([]gin.H, error) {
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days).Unix()
+	query := `SELECT checked_at / 86400 AS day, result, COUNT(*) FROM verifications WHERE checked_at >= $1`
+	args := []any{since}
+	if domain != "" {
+		query += ` AND domain = $2`
+		args = append(args, domain)
+	}
+	rows, err := h.db.Query(query+` GROUP BY day, result ORDER BY day`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byDay := make(map[int64]gin.H)
+	for rows.Next() {
+		var day int64
+		var result string
+		var n int
+		if err := rows.Scan(&day, &result, &n); err != nil {
+			return nil, err
+		}
+		d, ok := byDay[day]
+		if !ok {
+			d = gin.H{"total": 0, "results": gin.H{}}
+			byDay[day] = d
+		}
+		d["total"] = d["total"].(int) + n
+		d["results"].(gin.H)[result] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]gin.H, days)
+	for i := range out {
+		day := since/86400 + int64(i)
+		d, ok := byDay[day]
+		if !ok {
+			d = gin.H{"total": 0, "results": gin.H{}}
+		}
+		d["date"] = time.Unix(day*86400, 0).UTC().Format("2006-01-02")
+		out[i] = d
+	}
+	return out, nil
+}
+
+// Abort history requests when no database is configured
+func requireHistory(c *gin.Context) bool {
+	if history == nil {
+		c.JSON(404, gin.H{"error": apiError("history_disabled", "Verification history disabled")})
+		return false
+	}
+	return true
+}
+
+// limit query parameter of the history endpoints
+func historyLimit(c *gin.Context) (int, bool) {
+	v := c.Query("limit")
+	if v == "" {
+		return defaultHistoryLimit, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxHistoryLimit {
+		c.JSON(400, gin.H{"error": apiError("invalid_limit", "Invalid limit"), "max": maxHistoryLimit})
+		return 0, false
+	}
+	return n, true
+}
+
+// GET /admin/history/emails/:email[?limit=]
+func emailHistoryHandler(c *gin.Context) {
+	if !requireHistory(c) {
+		return
+	}
+	email, ok := cleanEmail(c.Param("email"))
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_email", "Invalid email")})
+		return
+	}
+	limit, ok := historyLimit(c)
+	if !ok {
+		return
+	}
+	rows, err := history.recent("email_hash", emailHash(foldGmailDots(c.Request.Context(), email)), limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "history query failed", "error", err)
+		c.JSON(500, gin.H{"error": apiError("internal_error", "History query failed")})
+		return
+	}
+	c.JSON(200, gin.H{"email": email, "count": len(rows), "verifications": rows})
+}
+
+// GET /admin/history/domains/:domain[?limit=]
+func domainHistoryHandler(c *gin.Context) {
+	if !requireHistory(c) {
+		return
+	}
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Param("domain"))), "."))
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	limit, ok := historyLimit(c)
+	if !ok {
+		return
+	}
+	rows, err := history.recent("domain", domain, limit)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "history query failed", "error", err)
+		c.JSON(500, gin.H{"error": apiError("internal_error", "History query failed")})
+		return
+	}
+	c.JSON(200, gin.H{"domain": domain, "count": len(rows), "verifications": rows})
+}
+
+// GET /admin/history/trends[?days=30&domain=]
+func historyTrendsHandler(c *gin.Context) {
+	if !requireHistory(c) {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 366 {
+		c.JSON(400, gin.H{"error": apiError("invalid_days", "days must be 1 to 366")})
+		return
+	}
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Query("domain"))), "."))
+	trend, err := history.trends(days, domain)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "history query failed", "error", err)
+		c.JSON(500, gin.H{"error": apiError("internal_error", "History query failed")})
+		return
+	}
+	res := gin.H{"days": days, "trend": trend}
+	if domain != "" {
+		res["domain"] = domain
+	}
+	c.JSON(200, res)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How long a response is replayed for a given Idempotency-Key
+const idempotencyTTL = 24 * time.Hour
+
+// Longest accepted Idempotency-Key
+const maxIdempotencyKeyLen = 255
+
+type idempotentResponse struct {
+	fingerprint [32]byte
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+	stored      time.Time
+}
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+var idempotency = &idempotencyStore{entries: make(map[string]*idempotentResponse)}
+
+// Response writer that keeps a copy of everything written
+type recordingWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Replay the stored response when a request repeats its Idempotency-Key, so
+// a retried POST never triggers a second SMTP probe. Keys are scoped to the
+// route and bound to the request body; 5xx responses are not kept.
+func idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(400, gin.H{"error": apiError("invalid_idempotency_key", "Idempotency-Key too long"), "max": maxIdempotencyKeyLen})
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": apiError("unreadable_body", "Unreadable body")})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(append([]byte(c.Request.URL.RawQuery+"\n"), body...))
+		storeKey := apiKeyName(c) + " " + c.FullPath() + " " + key
+
+		s := idempotency
+		s.mu.Lock()
+		for k, e := range s.entries {
+			if e.done && time.Since(e.stored) > idempotencyTTL {
+				delete(s.entries, k)
+			}
+		}
+		if e, ok := s.entries[storeKey]; ok {
+			s.mu.Unlock()
+			switch {
+			case e.fingerprint != fingerprint:
+				c.AbortWithStatusJSON(422, gin.H{"error": apiError("idempotency_key_reused", "Idempotency-Key reused with a different request")})
+			case !e.done:
+				c.AbortWithStatusJSON(409, gin.H{"error": apiError("request_in_progress", "A request with this Idempotency-Key is still in progress")})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(e.status, e.contentType, e.body)
+				c.Abort()
+			}
+			return
+		}
+		entry := &idempotentResponse{fingerprint: fingerprint}
+		s.entries[storeKey] = entry
+		s.mu.Unlock()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			status := w.Status()
+			if !c.Writer.Written() || status >= 500 {
+				delete(s.entries, storeKey) // let the client retry
+				return
+			}
+			entry.done = true
+			entry.status = status
+			entry.contentType = w.Header().Get("Content-Type")
+			entry.body = w.buf.Bytes()
+			entry.stored = time.Now()
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sender and EHLO name an SMTP session presents
+type smtpIdentity struct {
+	mailFrom string // "<>" for the null sender
+	helo     string
+}
+
+// Senders used for MAIL FROM (MAIL_FROM), taken in turn; "<>" is the null
+// sender, which some servers refuse and others prefer to an unknown one
+var mailFroms = []string{"rmtomal@tm71.top"}
+
+// Names sent in EHLO (HELO_NAMES), taken in turn; none falls back to the
+// detected hostname
+var heloNames []string
+
+// EHLO name used without HELO_NAMES, detected once
+var fallbackHeloName = sync.OnceValue(detectHeloName)
+
+var identityTurn atomic.Uint64
+
+// Report whether s can be sent as MAIL FROM
+func validSender(s string) bool {
+	return s == "<>" || validateSyntax(s).Valid
+}
+
+// Report whether s can be sent in EHLO: a domain name or an address
+// literal such as [192.0.2.1]
+func validHeloName(s string) bool {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return strings.Count(s, "[") == 1 && strings.Count(s, "]") == 1 && len(s) > 2
+	}
+	return strings.Contains(s, ".") && checkDomainSyntax(s) == ""
+}
+
+// Split a comma-separated list, checking every entry with valid
+func identityList(v string, valid func(string) bool, want string) ([]string, error) {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if !valid(s) {
+			return nil, fmt.Errorf("want %s", want)
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("want %s", want)
+	}
+	return out, nil
+}
+
+func parseMailFroms(v string) (err error) {
+	mailFroms, err = identityList(v, validSender, "email addresses or <>")
+	return err
+}
+
+func parseHeloNames(v string) (err error) {
+	heloNames, err = identityList(v, validHeloName, "domain names or address literals")
+	return err
+}
+
+type identityCtxKey struct{}
+
+// Present id's non-empty fields in the SMTP sessions run under ctx instead
+// of the configured ones
+func withSMTPIdentity(ctx context.Context, id smtpIdentity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+// Identity of the next session: the next sender and EHLO name in turn,
+// overridden by whatever was asked for under ctx
+func pickIdentity(ctx context.Context) smtpIdentity {
+	turn := identityTurn.Add(1) - 1
+	id := smtpIdentity{mailFrom: mailFroms[turn%uint64(len(mailFroms))]}
+	if len(heloNames) > 0 {
+		id.helo = heloNames[turn%uint64(len(heloNames))]
+	}
+	if asked, ok := ctx.Value(identityCtxKey{}).(smtpIdentity); ok {
+		if asked.mailFrom != "" {
+			id.mailFrom = asked.mailFrom
+		}
+		if asked.helo != "" {
+			id.helo = asked.helo
+		}
+	}
+	if id.helo == "" {
+		id.helo = fallbackHeloName()
+	}
+	return id
+}
+
+// EHLO name for a session under ctx
+func pickHeloName(ctx context.Context) string {
+	return pickIdentity(ctx).helo
+}
+
+// Work out a name for EHLO: the hostname when fully qualified, else the
+// reverse DNS name of the address outbound traffic leaves from, else that
+// address as a literal. Generic cloud names often fare worse than a
+// configured HELO_NAMES.
+func detectHeloName() string {
+	if name, err := os.Hostname(); err == nil && validHeloName(name) {
+		return name
+	}
+	conn, err := net.Dial("udp", "8.8.8.8:80")
+	if err != nil {
+		return "localhost"
+	}
+	defer conn.Close()
+	ip := conn.LocalAddr().(*net.UDPAddr).IP
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if names, err := net.DefaultResolver.LookupAddr(ctx, ip.String()); err == nil && len(names) > 0 {
+		if name := strings.TrimSuffix(names[0], "."); validHeloName(name) {
+			return name
+		}
+	}
+	if ip.To4() == nil {
+		return "[IPv6:" + ip.String() + "]"
+	}
+	return "[" + ip.String() + "]"
+}
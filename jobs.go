@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Largest list accepted by an async job
+const maxJobEmails = 100000
+
+// How long finished jobs are kept around for polling
+const jobRetention = 24 * time.Hour
+
+// Delay before a job checks its greylisted addresses again; 0 disables the
+// retry (GREYLIST_RETRY_DELAY)
+var greylistRetryDelay = 5 * time.Minute
+
+// Background bulk verification
+type job struct {
+	mu         sync.Mutex
+	id         string
+	status     string // queued, running, done
+	emails     []string
+	results    []gin.H
+	completed  int
+	order      []int         // result indexes in completion order
+	changed    chan struct{} // closed and replaced whenever the job advances
+	createdAt  time.Time
+	finishedAt time.Time
+	retryAt    time.Time // when greylisted addresses are checked again
+
+	apiKey string // name of the key that created the job, "" when keys are off
+
+	callbackURL    string
+	callbackStatus string // pending, delivered, failed
+	callbackError  string
+}
+
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+var jobs = &jobStore{jobs: make(map[string]*job)}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Register a job and start processing it in the background
+func (s *jobStore) create(emails []string, callbackURL, apiKey string) *job {
+	j := &job{
+		id:          newJobID(),
+		status:      "queued",
+		emails:      emails,
+		results:     make([]gin.H, len(emails)),
+		createdAt:   time.Now(),
+		changed:     make(chan struct{}),
+		callbackURL: callbackURL,
+		apiKey:      apiKey,
+	}
+	if callbackURL != "" {
+		j.callbackStatus = "pending"
+	}
+
+	s.mu.Lock()
+	for id, old := range s.jobs {
+		old.mu.Lock()
+		expired := old.status == "done" && time.Since(old.finishedAt) > jobRetention
+		old.mu.Unlock()
+		if expired {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[j.id] = j
+	s.mu.Unlock()
+
+	busy.Add(1)
+	go j.run()
+	return j
+}
+
+func (s *jobStore) get(id string) (*job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+// Jobs held with the given status
+func (s *jobStore) count(status string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, j := range s.jobs {
+		j.mu.Lock()
+		if j.status == status {
+			n++
+		}
+		j.mu.Unlock()
+	}
+	return n
+}
+
+// The job named in the URL, if it belongs to the caller's API key; other
+// clients' jobs look like missing ones
+func requestedJob(c *gin.Context) (*job, bool) {
+	j, ok := jobs.get(c.Param("id"))
+	if !ok || j.apiKey != apiKeyName(c) {
+		return nil, false
+	}
+	return j, true
+}
+
+func (j *job) run() {
+	defer busy.Add(-1)
+	j.mu.Lock()
+	j.status = "running"
+	j.broadcast()
+	j.mu.Unlock()
+
+	checkBulk(hardStop, j.emails, func(i int, res gin.H) {
+		j.mu.Lock()
+		j.results[i] = res
+		j.completed++
+		j.order = append(j.order, i)
+		j.broadcast()
+		j.mu.Unlock()
+	})
+	j.retryGreylisted()
+
+	j.mu.Lock()
+	j.status = "done"
+	j.finishedAt = time.Now()
+	j.broadcast()
+	j.mu.Unlock()
+
+	if j.callbackURL != "" {
+		j.notify()
+	}
+}
+
+// Wait out greylistRetryDelay and check the greylisted addresses again,
+// replacing their results with the new verdict. Replaced results are
+// appended to order once more so streams deliver them.
+func (j *job) retryGreylisted() {
+	if greylistRetryDelay <= 0 {
+		return
+	}
+	j.mu.Lock()
+	var idxs []int
+	var emails []string
+	for i, res := range j.results {
+		if res["reason"] == "greylisted" {
+			idxs = append(idxs, i)
+			emails = append(emails, j.emails[i])
+		}
+	}
+	if len(idxs) == 0 {
+		j.mu.Unlock()
+		return
+	}
+	j.retryAt = time.Now().Add(greylistRetryDelay)
+	j.broadcast()
+	j.mu.Unlock()
+	defer func() {
+		j.mu.Lock()
+		j.retryAt = time.Time{}
+		j.mu.Unlock()
+	}()
+
+	// On shutdown the greylisted results stay as they are
+	select {
+	case <-time.After(greylistRetryDelay):
+	case <-hardStop.Done():
+		return
+	}
+	checkBulk(hardStop, emails, func(k int, res gin.H) {
+		if hardStop.Err() != nil {
+			return // cut short by shutdown, not a new verdict
+		}
+		res["greylist_retried"] = true
+		if d, ok := res["duplicate_of"].(int); ok {
+			res["duplicate_of"] = idxs[d] // index into emails, not the retry list
+		}
+		j.mu.Lock()
+		j.results[idxs[k]] = res
+		j.order = append(j.order, idxs[k])
+		j.broadcast()
+		j.mu.Unlock()
+	})
+}
+
+// Wake everyone waiting on the job; caller must hold j.mu
+func (j *job) broadcast() {
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// Attempts made to deliver a completion webhook
+const callbackAttempts = 3
+
+// Delivers callbacks to public addresses only, checked on every dial so
+// neither a redirect nor a name re-resolved after the job was created can
+// reach the internal network. No environment proxy: it would dial for us.
+var callbackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: refusePrivate}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return errors.New("callback redirected away from https")
+		}
+		if len(via) >= 5 {
+			return errors.New("too many callback redirects")
+		}
+		return nil
+	},
+}
+
+// POST the finished job to its callback URL, retrying with backoff
+func (j *job) notify() {
+	j.mu.Lock()
+	payload, err := json.Marshal(gin.H{
+		"id":          j.id,
+		"status":      j.status,
+		"total":       len(j.emails),
+		"results_url": "/jobs/" + j.id + "/results",
+		"results":     j.results,
+	})
+	j.mu.Unlock()
+	if err != nil {
+		j.setCallbackResult(err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = postCallback(j.callbackURL, payload)
+		if err == nil || attempt == callbackAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-hardStop.Done():
+			j.setCallbackResult(fmt.Errorf("%w (retries cut short by shutdown)", err))
+			return
+		}
+		backoff *= 2
+	}
+	j.setCallbackResult(err)
+}
+
+func postCallback(target string, payload []byte) error {
+	resp, err := callbackClient.Post(target, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (j *job) setCallbackResult(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		slog.Warn("job callback failed", "job", j.id, "error", err)
+		j.callbackStatus = "failed"
+		j.callbackError = err.Error()
+		return
+	}
+	j.callbackStatus = "delivered"
+}
+
+// Accept only absolute https callback URLs
+func validCallbackURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// Check that a callback URL's host resolves to public addresses only.
+// A name that doesn't resolve yet is let through; delivery reports it.
+func publicCallbackHost(ctx context.Context, raw string) bool {
+	u, _ := url.Parse(raw)
+	_, err := publicAddrs(ctx, u.Hostname())
+	return !errors.Is(err, errPrivateAddress)
+}
+
+// Status body for GET /jobs/:id; caller must hold j.mu
+func (j *job) summary() gin.H {
+	res := gin.H{
+		"id":         j.id,
+		"status":     j.status,
+		"total":      len(j.emails),
+		"completed":  j.completed,
+		"progress":   float64(j.completed) / float64(len(j.emails)),
+		"created_at": j.createdAt,
+	}
+	if j.apiKey != "" {
+		res["api_key"] = j.apiKey
+	}
+	if j.status == "done" {
+		res["finished_at"] = j.finishedAt
+	}
+	if !j.retryAt.IsZero() {
+		res["greylist_retry_at"] = j.retryAt
+	}
+	if j.callbackURL != "" {
+		cb := gin.H{"url": j.callbackURL, "status": j.callbackStatus}
+		if j.callbackError != "" {
+			cb["error"] = j.callbackError
+		}
+		res["callback"] = cb
+	}
+	return res
+}
+
+// POST /jobs {"emails": [...], "callback_url": "https://..."}
+func createJobHandler(c *gin.Context) {
+	var body struct {
+		Emails      []string `json:"emails"`
+		CallbackURL string   `json:"callback_url"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	if len(body.Emails) == 0 {
+		c.JSON(400, gin.H{"error": apiError("no_emails", "No emails provided")})
+		return
+	}
+	if len(body.Emails) > maxJobEmails {
+		c.JSON(400, gin.H{"error": apiError("too_many_emails", "Too many emails"), "max": maxJobEmails})
+		return
+	}
+
+	if body.CallbackURL != "" && !validCallbackURL(body.CallbackURL) {
+		c.JSON(400, gin.H{"error": apiError("invalid_callback_url", "Invalid callback_url, use an https URL")})
+		return
+	}
+	if body.CallbackURL != "" && !publicCallbackHost(c.Request.Context(), body.CallbackURL) {
+		c.JSON(400, gin.H{"error": apiError("forbidden_callback_url", "callback_url resolves to a private or loopback address")})
+		return
+	}
+	if !chargeQuota(c, len(body.Emails)) {
+		return
+	}
+
+	j := jobs.create(body.Emails, body.CallbackURL, apiKeyName(c))
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	c.JSON(202, j.summary())
+}
+
+// GET /jobs/:id
+func jobStatusHandler(c *gin.Context) {
+	j, ok := requestedJob(c)
+	if !ok {
+		c.JSON(404, gin.H{"error": apiError("job_not_found", "Job not found")})
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	c.JSON(200, j.summary())
+}
+
+// Page size limits of GET /jobs/:id/results
+const (
+	defaultResultsLimit = 1000
+	maxResultsLimit     = 10000
+)
+
+// GET /jobs/:id/results[?limit=&cursor=&status=]
+//
+// Without limit or cursor every result is returned at once. The cursor is
+// the index to resume from, handed out as next_cursor.
+func jobResultsHandler(c *gin.Context) {
+	j, ok := requestedJob(c)
+	if !ok {
+		c.JSON(404, gin.H{"error": apiError("job_not_found", "Job not found")})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && !slices.Contains(canonicalResults, status) {
+		c.JSON(400, gin.H{"error": apiError("invalid_status", "Invalid status, use a result value such as deliverable, undeliverable or unknown")})
+		return
+	}
+	_, paged := c.GetQuery("limit")
+	if _, ok := c.GetQuery("cursor"); ok {
+		paged = true
+	}
+	limit, start := defaultResultsLimit, 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxResultsLimit {
+			c.JSON(400, gin.H{"error": apiError("invalid_limit", "Invalid limit"), "max": maxResultsLimit})
+			return
+		}
+		limit = n
+	}
+	if v := c.Query("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(400, gin.H{"error": apiError("invalid_cursor", "Invalid cursor")})
+			return
+		}
+		start = n
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != "done" {
+		c.JSON(409, gin.H{"error": apiError("job_not_finished", "Job not finished"), "job": j.summary()})
+		return
+	}
+	if !paged && status == "" {
+		respond(c, 200, gin.H{"id": j.id, "count": len(j.results), "results": j.results})
+		return
+	}
+
+	page := []gin.H{}
+	i := start
+	for ; i < len(j.results); i++ {
+		if paged && len(page) == limit {
+			break
+		}
+		if status == "" || j.results[i]["result"] == status {
+			page = append(page, j.results[i])
+		}
+	}
+	res := gin.H{"id": j.id, "total": len(j.results), "count": len(page), "results": page, "next_cursor": nil}
+	if i < len(j.results) {
+		res["next_cursor"] = strconv.Itoa(i)
+	}
+	respond(c, 200, res)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateJobRefusesCallbackURLs(t *testing.T) {
+	for _, tc := range []struct {
+		url, code string
+	}{
+		{"http://hooks.example.com/done", "invalid_callback_url"},
+		{"https://127.0.0.1/done", "forbidden_callback_url"},
+		{"https://[::1]:8443/done", "forbidden_callback_url"},
+		{"https://169.254.169.254/latest", "forbidden_callback_url"},
+		{"https://10.0.0.7/done", "forbidden_callback_url"},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"emails": ["a@example.com"], "callback_url": "` + tc.url + `"}`
+		c.Request = httptest.NewRequest("POST", "/jobs", strings.NewReader(body))
+		createJobHandler(c)
+		var got struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if w.Code != 400 || got.Error.Code != tc.code {
+			t.Errorf("%s: %d %s, want 400 %s", tc.url, w.Code, w.Body, tc.code)
+		}
+	}
+}
+
+// Delivery re-checks the address it dials, whatever the URL was at creation
+func TestCallbackRefusesLoopback(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+	err := postCallback(srv.URL, []byte("{}"))
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("err = %v, want errPrivateAddress", err)
+	}
+}
+
+// Shutdown during the greylist wait must leave the greylisted results alone
+func TestRetryGreylistedStopsOnShutdown(t *testing.T) {
+	oldStop, oldDelay := hardStop, greylistRetryDelay
+	t.Cleanup(func() { hardStop, greylistRetryDelay = oldStop, oldDelay })
+	var stop context.CancelFunc
+	hardStop, stop = context.WithCancel(context.Background())
+	greylistRetryDelay = time.Hour
+	stop()
+
+	j := &job{
+		emails:  []string{"a@example.com"},
+		results: []gin.H{{"reason": "greylisted"}},
+		changed: make(chan struct{}),
+	}
+	j.retryGreylisted()
+	if res := j.results[0]; res["reason"] != "greylisted" || res["greylist_retried"] != nil {
+		t.Errorf("result = %v, want the greylisted one", res)
+	}
+	if !j.retryAt.IsZero() {
+		t.Errorf("retryAt = %v, want zero", j.retryAt)
+	}
+}
+
+// Shutdown must end the callback backoff and record the failure so far
+func TestNotifyStopsOnShutdown(t *testing.T) {
+	oldStop := hardStop
+	t.Cleanup(func() { hardStop = oldStop })
+	var stop context.CancelFunc
+	hardStop, stop = context.WithCancel(context.Background())
+	stop()
+
+	j := &job{id: "j1", callbackURL: "https://127.0.0.1/done", callbackStatus: "pending"}
+	start := time.Now()
+	j.notify()
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("notify took %v, want it to skip the backoff", d)
+	}
+	if j.callbackStatus != "failed" || !strings.Contains(j.callbackError, "shutdown") {
+		t.Errorf("callback = %s %q, want failed, cut short by shutdown", j.callbackStatus, j.callbackError)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline of a request (REQUEST_TIMEOUT), 0 for none. Checks still under
+// way when it passes answer 504 deadline_exceeded with what they found.
+var requestTimeout = time.Minute
+
+// Largest request body (MAX_REQUEST_BODY) and that of the routes taking
+// address lists (MAX_LIST_BODY), in bytes
+var (
+	maxRequestBody = 1 << 20
+	maxListBody    = 16 << 20
+)
+
+// Routes whose work grows with their input or that stream, which get no
+// deadline
+var untimedRoutes = map[string]bool{
+	"/email-check/bulk": true,
+	"/email-check/csv":  true,
+	"/ws":               true,
+	"/jobs/:id/stream":  true,
+	"/jobs/:id/export":  true,
+}
+
+// Routes taking address lists, allowed maxListBody
+var listRoutes = map[string]bool{
+	"/email-check/bulk": true,
+	"/email-check/csv":  true,
+	"/jobs":             true,
+	"/normalize":        true,
+}
+
+// Refuse bodies over the route's limit with 413. Bodies of unknown length
+// are read up front so handlers never see a truncated one.
+func limitBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := int64(maxRequestBody)
+		if listRoutes[c.FullPath()] {
+			limit = int64(maxListBody)
+		}
+		tooLarge := func() {
+			c.AbortWithStatusJSON(413, gin.H{"error": apiError("body_too_large", "Request body too large"), "max": limit})
+		}
+		if c.Request.ContentLength > limit {
+			tooLarge()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(c.Request.Body)
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				tooLarge()
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(400, gin.H{"error": apiError("unreadable_body", "Unreadable body")})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		c.Next()
+	}
+}
+
+// Give the request's context requestTimeout to run, so SMTP sessions and
+// lookups still going when it passes are cut off
+func requestDeadline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestTimeout == 0 || untimedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), requestTimeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Answer a request whose context ended before its work was done, with the
+// findings so far under "partial" when it was the deadline. Reports
+// whether it did.
+func abandoned(c *gin.Context, partial gin.H) bool {
+	err := c.Request.Context().Err()
+	if err == nil {
+		return false
+	}
+	c.JSON(partialResult(err, partial))
+	return true
+}
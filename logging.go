@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Least severe level logged (LOG_LEVEL): debug, info, warn or error
+var logLevel = new(slog.LevelVar)
+
+// json for log aggregation, text for reading in a terminal (LOG_FORMAT)
+var logFormat = "json"
+
+// Make slog, and through it the log package, write logFormat lines to
+// stderr at logLevel
+func setupLogging() {
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var h slog.Handler = slog.NewJSONHandler(os.Stderr, opts)
+	if logFormat == "text" {
+		h = slog.NewTextHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	// gin's route listing and warnings are plain text on stdout
+	if logLevel.Level() > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+}
+
+// Log at error level and exit
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// Adds the request ID, API key name and trace ID found in the context to
+// every record logged with one
+type contextHandler struct{ slog.Handler }
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if name := ctxAPIKeyName(ctx); name != "" {
+		r.AddAttrs(slog.String("api_key", name))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+// ID of the request being served, "" outside of one
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Caller-supplied request IDs longer than this are replaced
+const maxRequestIDLength = 128
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Tag each request with an ID (the caller's X-Request-ID, or a new one),
+// echoed in the response and attached to everything logged while serving
+// it, then log the request once it is done
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		c.Header("X-Request-ID", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Log a served verification: the domain but never the address, result and
+// how long it took
+func logVerification(ctx context.Context, email string, started time.Time, res gin.H) {
+	attrs := []slog.Attr{
+		slog.String("domain", emailDomain(email)),
+		slog.Any("result", res["result"]),
+		slog.Any("score", res["score"]),
+		slog.Int64("duration_ms", time.Since(started).Milliseconds()),
+	}
+	if cached, ok := res["from_cache"].(bool); ok {
+		attrs = append(attrs, slog.Bool("from_cache", cached))
+	}
+	if reason, _ := res["reason"].(string); reason != "" {
+		attrs = append(attrs, slog.String("reason", reason))
+	}
+	if code, _, failed := resultError(res); failed {
+		attrs = append(attrs, slog.String("error_code", code))
+	}
+	if mxHost, _ := res["mx_host"].(string); mxHost != "" {
+		attrs = append(attrs, slog.String("mx_host", mxHost))
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "verification", attrs...)
+}
+
+// Domain part of an address, for logs that must not carry the address
+func emailDomain(email string) string {
+	return email[strings.LastIndex(email, "@")+1:]
+}
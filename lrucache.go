@@ -0,0 +1,108 @@
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Entries the lru backend keeps before evicting (RESULT_CACHE_MAX_ENTRIES)
+var resultCacheMaxEntries = 100000
+
+// In-memory result cache bounded to max entries, evicting the least
+// recently used one when full. Expiry works like the memory backend's.
+type lruCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	email string
+	entry cacheEntry
+}
+
+func newLRUCache(ttl time.Duration, max int) *lruCache {
+	return &lruCache{ttl: ttl, max: max, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (lc *lruCache) get(email string) (cacheEntry, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	el, ok := lc.entries[email]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	item := el.Value.(*lruItem)
+	if time.Since(item.entry.stored) > lc.ttl {
+		lc.order.Remove(el)
+		delete(lc.entries, email)
+		return cacheEntry{}, false
+	}
+	lc.order.MoveToFront(el)
+	return item.entry, true
+}
+
+func (lc *lruCache) set(email string, body gin.H) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	entry := cacheEntry{body: copyH(body), stored: time.Now()}
+	if el, ok := lc.entries[email]; ok {
+		el.Value.(*lruItem).entry = entry
+		lc.order.MoveToFront(el)
+		return
+	}
+	lc.entries[email] = lc.order.PushFront(&lruItem{email, entry})
+	for lc.order.Len() > lc.max {
+		oldest := lc.order.Back()
+		lc.order.Remove(oldest)
+		delete(lc.entries, oldest.Value.(*lruItem).email)
+	}
+}
+
+func (lc *lruCache) purge(email string) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	el, ok := lc.entries[email]
+	if ok {
+		lc.order.Remove(el)
+		delete(lc.entries, email)
+	}
+	return ok
+}
+
+func (lc *lruCache) purgeDomain(domain string) int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	n := 0
+	for email, el := range lc.entries {
+		if strings.HasSuffix(email, "@"+domain) {
+			lc.order.Remove(el)
+			delete(lc.entries, email)
+			n++
+		}
+	}
+	return n
+}
+
+func (lc *lruCache) purgeAll() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	n := len(lc.entries)
+	lc.order.Init()
+	lc.entries = make(map[string]*list.Element)
+	return n
+}
+
+func (lc *lruCache) size() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return len(lc.entries)
+}
+
+func (lc *lruCache) ping() error { return nil }
@@ -1,207 +1,738 @@
 package main
 
 import (
-	"bufio"
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"net"
-	"strconv"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
-type smtpResult struct {
-	logs  map[string]string
-	err   error
-	email string
+// Trim an address, lowercase its domain and reduce its local part to the
+// simplest form, reporting whether it looks like an email. Gmail spellings
+// are kept as given: the probe, the cache and deduplication fold them with
+// foldGmailDots, avatar lookups need the exact address.
+func cleanEmail(raw string) (string, bool) {
+	email := strings.TrimSpace(raw)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return strings.ToLower(email), false
+	}
+	return canonicalLocalPart(email[:at]) + "@" + strings.ToLower(email[at+1:]), true
 }
 
-// Detect local hostname for EHLO
-func getMyHostname() string {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
-	if err != nil {
-		return "localhost"
+// Resolve the MX hosts of a domain, most preferred first. A domain without
+// MX records that has an A/AAAA record is its own implicit MX (RFC 5321 5.1);
+// one publishing a null MX gets errNullMX.
+func lookupMXHosts(ctx context.Context, domain string) ([]string, error) {
+	mxRecords, err := lookupMX(ctx, domain)
+	if len(mxRecords) == 1 && mxRecords[0].Host == "." {
+		return nil, errNullMX
 	}
-	defer conn.Close()
-	ip := conn.LocalAddr().(*net.UDPAddr).IP.String()
-	names, err := net.LookupAddr(ip)
-	if err != nil || len(names) == 0 {
-		return ip
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return nil, err
 	}
-	return strings.TrimSuffix(names[0], ".")
+	if len(mxRecords) == 0 {
+		addrs, aErr := lookupHost(ctx, domain)
+		if aErr == nil && len(addrs) > 0 {
+			return []string{domain}, nil
+		}
+		if aErr != nil && !(errors.As(aErr, &dnsErr) && dnsErr.IsNotFound) {
+			return nil, aErr // the fallback failed rather than found nothing
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, &net.DNSError{Err: "no MX records", Name: domain, IsNotFound: true}
+	}
+	hosts := make([]string, len(mxRecords))
+	for i, mx := range mxRecords {
+		hosts[i] = strings.TrimSuffix(mx.Host, ".")
+	}
+	return hosts, nil
 }
 
-// Perform basic SMTP check
-func smtpCheck(mxHost, mailFrom, rcptTo string) smtpResult {
-	logs := make(map[string]string)
-	hostName := getMyHostname()
-
-	println(hostName)
-
-	conn, err := net.Dial("tcp", mxHost+":25")
+// Resolve the preferred MX host for a domain
+func lookupMXHost(ctx context.Context, domain string) (string, error) {
+	hosts, err := lookupMXHosts(ctx, domain)
 	if err != nil {
-		logs["connection"] = fmt.Sprintf("connection error: %v", err)
-		return smtpResult{logs, err, rcptTo}
+		return "", err
 	}
-	defer conn.Close()
-	reader := bufio.NewReader(conn)
-	logs["connection"] = "connected"
+	return hosts[0], nil
+}
 
-	// Read server banner
-	banner, _ := reader.ReadString('\n')
-	logs["banner"] = strings.TrimSpace(banner)
+// SMTP conversations tried per check when the first ends in a transient
+// failure, and the backoff before the second; SMTP_RETRY_* override them
+var (
+	smtpRetryAttempts  = 3
+	smtpRetryBaseDelay = time.Second
+)
 
-	sendEHLO := func(c net.Conn) (bool, error) {
-		_, err := fmt.Fprintf(c, "EHLO %s\r\n", hostName)
-		if err != nil {
-			return false, err
-		}
-		hasStartTLS := false
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				break
-			}
-			if strings.Contains(strings.ToUpper(line), "STARTTLS") {
-				hasStartTLS = true
-			}
-			if len(line) < 4 || line[3] != '-' {
-				break
+// Whether a probe failed in a way worth retrying soon: a dropped or timed
+// out connection, or a 4xx RCPT reply. Greylisting is left to the delayed
+// job retry and a full mailbox won't empty within seconds either.
+func isTransient(res smtpResult) bool {
+	code := rcptCode(res)
+	return res.timedOut || isTimeout(res.err) || isConnDropped(res.err) ||
+		code >= 400 && code < 500 && !isGreylisted(res.rcpt) && !isMailboxFull(res.rcpt)
+}
+
+// Jittered exponential backoff before retry n (1-based): a random wait
+// between half and all of base*2^(n-1)
+func retryBackoff(n int) time.Duration {
+	d := smtpRetryBaseDelay << (n - 1)
+	return d/2 + rand.N(d/2+1)
+}
+
+// Probe the recipients via smtpCheckHosts, retrying transient failures with
+// backoff up to smtpRetryAttempts times. Every result records the number of
+// attempts made.
+func smtpCheckMX(ctx context.Context, hosts []string, timeouts smtpTimeouts, rcpts ...string) (string, []smtpResult) {
+	for attempt := 1; ; attempt++ {
+		host, results := smtpCheckHosts(ctx, hosts, timeouts, rcpts...)
+		if attempt < smtpRetryAttempts && isTransient(results[0]) {
+			select {
+			case <-time.After(retryBackoff(attempt)):
+				continue
+			case <-ctx.Done():
 			}
 		}
-		return hasStartTLS, nil
-	}
-
-	// EHLO first
-	hasStartTLS, _ := sendEHLO(conn)
-	if hasStartTLS {
-		logs["ehlo_caps"] = "STARTTLS supported"
-		fmt.Fprintf(conn, "STARTTLS\r\n")
-		resp, _ := reader.ReadString('\n')
-		if strings.HasPrefix(resp, "220") {
-			tlsConn := tls.Client(conn, &tls.Config{
-				ServerName:         mxHost,
-				InsecureSkipVerify: true,
-			})
-			if err := tlsConn.Handshake(); err == nil {
-				conn = tlsConn
-				reader = bufio.NewReader(conn)
-				logs["tls"] = "TLS handshake successful"
-				sendEHLO(conn) // EHLO after TLS
-			} else {
-				logs["tls"] = fmt.Sprintf("TLS handshake failed: %v", err)
+		for i := range results {
+			results[i].attempts = attempt
+		}
+		return host, results
+	}
+}
+
+// Run one smtpSession for the recipients against the MX hosts in priority
+// order, moving on while a host refuses or times out the connection.
+// Returns the host that answered (or the last one tried).
+func smtpCheckHosts(ctx context.Context, hosts []string, timeouts smtpTimeouts, rcpts ...string) (string, []smtpResult) {
+	var skipped []string
+	for i := 0; ; i++ {
+		results := smtpSession(ctx, hosts[i], timeouts, rcpts...)
+		if results[0].connected || i == len(hosts)-1 || ctx.Err() != nil {
+			for _, res := range results {
+				if len(skipped) > 0 {
+					res.logs["mx_fallback"] = strings.Join(skipped, "; ")
+				}
+			}
+			if !results[0].connected && ctx.Err() == nil {
+				// No MX took port 25: see whether it's our outbound port
+				// that's blocked. Open circuits are no exception, since
+				// a port 25 block opens every circuit.
+				sub := results[0]
+				if probeSubmission(ctx, hosts[i], timeouts, &sub) {
+					for j := range results {
+						email := results[j].email
+						results[j] = sub
+						results[j].email = email
+					}
+				}
 			}
+			return hosts[i], results
 		}
+		skipped = append(skipped, fmt.Sprintf("%s: %v", hosts[i], results[0].err))
+	}
+}
+
+// Address that should not exist, used to detect catch-all domains. Each
+// probe gets a fresh one so servers can't special-case it.
+func catchAllProbeAddress(domain string) string {
+	return randomLocalPart() + "@" + domain
+}
+
+// Body of a check abandoned because its context ended: the client went
+// away or the deadline passed. Such results are never cached.
+func canceledResult(err error) (int, gin.H) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return 504, gin.H{"error": apiError("deadline_exceeded", "Verification deadline exceeded")}
 	}
+	return 499, gin.H{"error": apiError("canceled", "Request canceled")}
+}
 
-	// MAIL FROM
-	fmt.Fprintf(conn, "MAIL FROM:<%s>\r\n", mailFrom)
-	mailResp, _ := reader.ReadString('\n')
-	if !strings.HasPrefix(mailResp, "250") {
-		logs["mail_from"] = fmt.Sprintf("MAIL FROM rejected: %s", strings.TrimSpace(mailResp))
-		return smtpResult{logs, fmt.Errorf("MAIL FROM rejected"), rcptTo}
+// canceledResult with what the work had found before its deadline passed
+// under "partial"
+func partialResult(err error, partial gin.H) (int, gin.H) {
+	status, body := canceledResult(err)
+	if status == 504 {
+		body["partial"] = partial
 	}
-	logs["mail_from"] = "MAIL FROM accepted"
+	return status, body
+}
 
-	// RCPT TO
-	fmt.Fprintf(conn, "RCPT TO:<%s>\r\n", rcptTo)
-	var rcptResp string
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			break
+// Verify a normalized email as deep as opts.depth goes, returning the HTTP
+// status and response body. Ending ctx aborts the MX lookup and closes the
+// SMTP connection.
+func checkEmail(ctx context.Context, email string, opts checkOptions) (int, gin.H) {
+	ctx, span := tracer.Start(ctx, "verify", trace.WithAttributes(
+		attribute.String("email.domain", emailDomain(email)),
+		attribute.String("depth", opts.depth),
+	))
+	defer span.End()
+	if opts.proxy != "" {
+		ctx = withSMTPProxy(ctx, opts.proxy)
+	}
+	if opts.identity != (smtpIdentity{}) {
+		ctx = withSMTPIdentity(ctx, opts.identity)
+	}
+	if res := invalidSyntaxResult(email); res != nil {
+		return 200, res
+	}
+	if res := lowQualityResult(email); res != nil {
+		return 200, withDepth(res, opts.depth)
+	}
+	if opts.depth == depthSyntax {
+		return 200, withDepth(shallowResult(email, ""), opts.depth)
+	}
+	// DNS and SMTP get the punycode form of an internationalized domain
+	at := strings.LastIndex(email, "@")
+	domain := asciiDomain(email[at+1:])
+	probeEmail := foldGmailDots(ctx, email[:at+1]+domain)
+	mxHosts, err := lookupMXHosts(ctx, domain)
+	if ctx.Err() != nil {
+		return partialResult(ctx.Err(), gin.H{"stage": "dns", "result": shallowResult(email, "")})
+	}
+	if errors.Is(err, errNullMX) {
+		return 200, withDepth(nullMXResult(email), opts.depth)
+	}
+	if err != nil {
+		return mxErrorResult(email, domain, err)
+	}
+	if opts.depth == depthDNS {
+		return 200, withDepth(shallowResult(email, mxHosts[0]), opts.depth)
+	}
+	release, err := workers.acquire(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return partialResult(ctx.Err(), gin.H{"stage": "queue", "result": shallowResult(email, mxHosts[0])})
 		}
-		rcptResp += line
-		if len(line) < 4 || line[3] != '-' {
-			break
+		return busyResult(err)
+	}
+	defer release()
+	deep := opts.depth == depthDeep
+	addAvatars, addAuth, addAge, addParked := func(gin.H) {}, func(gin.H) {}, func(gin.H) {}, func(gin.H) {}
+	if deep {
+		addAvatars = avatarSignal(ctx, email)
+		addAuth = domainAuthSignal(ctx, domain)
+		addAge = domainAgeSignal(ctx, domain)
+		addParked = parkedSignal(ctx, domain, mxHosts)
+	}
+
+	// Real and made-up recipient over one session, unless the domain's
+	// catch-all probe is cached or not wanted
+	rcpts := []string{probeEmail}
+	var res2 smtpResult
+	probeFake := false
+	if deep {
+		var cached bool
+		res2, cached = cachedCatchAllProbe(domain)
+		if probeFake = !cached; probeFake {
+			rcpts = append(rcpts, catchAllProbeAddress(domain))
 		}
 	}
-	logs["rcpt_to"] = strings.TrimSpace(rcptResp)
+	mxHost, probes := smtpCheckMX(ctx, mxHosts, opts.timeouts, rcpts...)
+	if ctx.Err() != nil {
+		partial := shallowResult(email, mxHost)
+		partial["smtp"] = smtpDetails(probes[0])
+		return partialResult(ctx.Err(), gin.H{"stage": "smtp", "result": partial})
+	}
+	res1 := probes[0]
+	if probeFake {
+		res2 = probes[1]
+		storeCatchAllProbe(domain, res2)
+	}
+	addConsensus := func(gin.H) {}
+	if opts.consensus && rcptCode(res1)/100 == 2 {
+		addConsensus = consensusSignal(ctx, mxHosts, mxHost, probeEmail, opts.timeouts, res1)
+	}
+	if !deep {
+		body := buildResult(mxHost, res1, res2)
+		addConsensus(body)
+		return 200, withDepth(body, opts.depth)
+	}
+
+	// Reputation and DANE are judged for the host that actually answered
+	addDANE := daneSignal(mxHost)
+	addBlacklists := blacklistSignal(ctx, mxHost)
+
+	body := buildResult(mxHost, res1, res2)
+	addAvatars(body)
+	addAuth(body)
+	addAge(body)
+	addParked(body)
+	addDANE(body, res1)
+	addBlacklists(body)
+	addConsensus(body)
+	applySpamtrapRisk(email, body)
+	return 200, withDepth(body, opts.depth)
+}
 
-	fmt.Fprintf(conn, "QUIT\r\n")
+// Reply code of the RCPT TO command, 0 if it was never answered
+func rcptCode(res smtpResult) int {
+	code, _, _ := parseReply(res.rcpt)
+	return code
+}
 
-	return smtpResult{logs, nil, rcptTo}
+// Verdict of a RCPT reply code by its family: 2xx deliverable, 4xx
+// temp_failure, 5xx undeliverable, anything else (incl. no reply) unknown
+func replyClass(code int) string {
+	switch code / 100 {
+	case 2:
+		return "deliverable"
+	case 4:
+		return "temp_failure"
+	case 5:
+		return "undeliverable"
+	}
+	return "unknown"
 }
 
-func smtpStatus(code int) string {
-	switch code {
-	case 250:
-		return "Deliverable"
-	case 550:
-		return "Mailbox unavailable / not found / relay denied"
+// Short machine-readable explanation of a probe outcome
+func probeReason(res smtpResult, catchAll bool) string {
+	code := rcptCode(res)
+	switch {
+	case errors.Is(res.err, errBlockedAfterBanner):
+		return "blocked_after_banner"
+	case !res.connected && res.port != 0:
+		return "port_25_blocked" // even with the circuit open
+	case errors.Is(res.err, errCircuitOpen):
+		return "mx_circuit_open"
+	case !res.connected:
+		return "connection_failed"
+	case errors.Is(res.err, errMailFromRejected):
+		return "mail_from_rejected"
+	case errors.Is(res.err, errRequiresSMTPUTF8):
+		return "requires_smtputf8"
+	case code == 250 && catchAll:
+		return "accept_all"
+	case code >= 200 && code < 300:
+		return "accepted"
+	case isGreylisted(res.rcpt):
+		return "greylisted"
+	case isMailboxFull(res.rcpt):
+		return "mailbox_full"
+	case code >= 400 && code < 500:
+		return "temporary_failure"
+	case isMailboxDisabled(res.rcpt):
+		return "mailbox_disabled"
+	case code >= 500:
+		return "rejected"
+	case res.timedOut:
+		return "timeout"
 	default:
-		return "Other SMTP response"
+		return "no_response"
 	}
 }
 
-func main() {
-	app := gin.Default()
-	app.POST("/email-check", func(c *gin.Context) {
-		var body map[string]interface{}
-		if err := c.BindJSON(&body); err != nil {
-			c.JSON(400, gin.H{"error": "Invalid JSON"})
-			return
+// What VRFY/EXPN said about the recipient: true or false when definitive,
+// nil when not asked or unanswerable (252 "cannot verify", not implemented)
+func vrfyVerdict(res smtpResult) interface{} {
+	switch code, _, _ := parseReply(res.vrfy); code {
+	case 250, 251:
+		return true
+	case 550, 551, 553:
+		return false
+	}
+	return nil
+}
+
+// Typed view of the SMTP session for a response body
+func smtpDetails(res smtpResult) gin.H {
+	out := gin.H{"connected": res.connected, "port": res.port, "banner": res.banner, "tls": res.tls}
+	if res.tlsError != "" {
+		out["tls_error"] = res.tlsError
+	}
+	if res.proxy != "" {
+		out["proxy"] = res.proxy
+	}
+	if res.sourceIP != "" {
+		out["source_ip"] = res.sourceIP
+	}
+	if res.addressFamily != "" {
+		out["address_family"] = res.addressFamily
+	}
+	if res.helo != "" {
+		out["helo"] = res.helo
+		out["mail_from"] = res.sender
+	}
+	if len(res.mailFrom) > 0 {
+		code, _, _ := parseReply(res.mailFrom)
+		out["mail_from_code"] = code
+	}
+	return out
+}
+
+// Typed view of the RCPT TO reply
+func rcptDetails(res smtpResult) gin.H {
+	code, enhanced, msg := parseReply(res.rcpt)
+	return gin.H{"code": code, "enhanced_code": enhanced, "enhanced_reason": enhancedReason(enhanced), "message": msg}
+}
+
+// Step log and timing of one SMTP session, for debug verbosity
+func probeDebug(res smtpResult) gin.H {
+	return gin.H{
+		"email":       res.email,
+		"started_at":  res.started,
+		"duration_ms": res.duration.Milliseconds(),
+		"log":         res.logs,
+		"transcript":  res.transcript,
+		"smtp":        smtpDetails(res),
+		"rcpt":        rcptDetails(res),
+	}
+}
+
+// Turn the real and catch-all probe outcomes into a response body
+func buildResult(mxHost string, res1, res2 smtpResult) gin.H {
+	// Catch-all stays unknown (nil) when the fake recipient got no answer
+	var catchAll interface{}
+	if fakeCode := rcptCode(res2); fakeCode != 0 {
+		catchAll = fakeCode == 250
+	}
+	isCatchAll := catchAll == true
+	platform := classifier.Platform(mxHost)
+
+	body := gin.H{
+		"mx_host":   mxHost,
+		"platform":  platform,
+		"smtp":      smtpDetails(res1),
+		"rcpt":      rcptDetails(res1),
+		"catch_all": catchAll,
+		"reason":    probeReason(res1, isCatchAll),
+		"debug": gin.H{
+			"probe":           probeDebug(res1),
+			"catch_all_probe": probeDebug(res2),
+		},
+	}
+
+	body["smtp_attempts"] = res1.attempts
+	body["tls_cert"] = evaluateCert(mxHost, res1.peerCerts)
+	classifyAddress(body, res1.email, mxHost)
+
+	if errors.Is(res1.err, errBlockedAfterBanner) {
+		body["status"] = res1.err.Error()
+		body["reply_class"] = "unknown"
+		body["isDeliverable"] = false
+		body["risky"] = false
+		body["hint"] = "the MX dropped the connection after its banner; retry from an IP with better reputation or through a proxy"
+		return body
+	}
+	if body["reason"] == "requires_smtputf8" {
+		body["hint"] = "the MX doesn't offer SMTPUTF8, so it can't take mail for a non-ASCII mailbox"
+	}
+	if body["reason"] == "port_25_blocked" {
+		body["hint"] = fmt.Sprintf("the MX answered on port %d but not on 25, so outbound port 25 is likely blocked here; run the verifier from a host that allows it", res1.port)
+	}
+
+	// Determine deliverability
+	code := rcptCode(res1)
+	isDeliverable := code == 250
+	body["status"] = smtpStatus(code)
+	body["reply_class"] = replyClass(code)
+	if body["reason"] == "mailbox_full" {
+		body["reply_class"] = "temp_failure" // RFC 5321 treats a 552 quota reply to RCPT as temporary
+	}
+	body["isDeliverable"] = isDeliverable
+	body["risky"] = isDeliverable && isCatchAll
+	applyPlatformHeuristics(body, platform, res1)
+	if res1.vrfyCmd != "" {
+		code, enhanced, msg := parseReply(res1.vrfy)
+		body["vrfy"] = gin.H{"command": res1.vrfyCmd, "code": code, "enhanced_code": enhanced, "message": msg, "exists": vrfyVerdict(res1)}
+	}
+	// A VRFY that denies the made-up recipient answers truthfully, which
+	// settles an otherwise accept-all domain
+	if exists, ok := vrfyVerdict(res1).(bool); ok && isCatchAll && vrfyVerdict(res2) == false {
+		body["isDeliverable"] = exists
+		body["risky"] = false
+		body["reason"] = "vrfy_rejected"
+		body["reply_class"] = "undeliverable"
+		if exists {
+			body["reason"] = "vrfy_confirmed"
+			body["reply_class"] = "deliverable"
 		}
+	}
+	if body["reason"] == "rejected" {
+		addSuggestion(res1.email, body)
+	}
+	return body
+}
+
+// Parameters of a single-address verification request
+type emailRequest struct {
+	email     string
+	mode      string // "syntax" skips DNS and SMTP
+	depth     string // syntax, dns, smtp or deep (default)
+	verbosity string // minimal, standard or debug
+	// Probe the address without its +tag; many providers accept any tag,
+	// so the tagged form says little about the mailbox
+	verifyBase bool
+	timeouts   map[string]string // raw smtpTimeoutParams overrides
+	consensus  bool
+	proxy      string // name of the SMTP proxy to use instead of the next in turn
+	mailFrom   string // sender to use instead of MAIL_FROM
+	helo       string // EHLO name to use instead of HELO_NAMES
+}
+
+// Per-request SMTP timeout parameters, as durations such as "10s"
+var smtpTimeoutParams = []string{"dial_timeout", "banner_timeout", "command_timeout", "session_timeout"}
 
-		email, ok := body["email"].(string)
-		if !ok || !strings.Contains(email, "@") {
-			c.JSON(400, gin.H{"error": "Invalid email"})
-			return
+// Options of the request's check, with its validated timeouts
+func (r emailRequest) checkOptions(timeouts smtpTimeouts) checkOptions {
+	opts := checkOptions{depth: r.depth, timeouts: timeouts, consensus: r.consensus, proxy: r.proxy,
+		identity: smtpIdentity{mailFrom: r.mailFrom, helo: r.helo}}
+	if opts.depth == "" {
+		opts.depth = depthDeep
+	}
+	return opts
+}
+
+// The request's SMTP timeouts: the defaults with its overrides applied;
+// false when one isn't a positive duration up to maxSMTPTimeout
+func (r emailRequest) smtpTimeouts() (smtpTimeouts, bool) {
+	t := defaultSMTPTimeouts
+	fields := map[string]*time.Duration{
+		"dial_timeout":    &t.dial,
+		"banner_timeout":  &t.banner,
+		"command_timeout": &t.command,
+		"session_timeout": &t.session,
+	}
+	for name, raw := range r.timeouts {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > maxSMTPTimeout {
+			return t, false
 		}
+		*fields[name] = d
+	}
+	return t, true
+}
 
-		email = strings.ToLower(strings.TrimSpace(email))
-		parts := strings.Split(email, "@")
-		domain := parts[1]
-		mxRecords, err := net.LookupMX(domain)
-		if err != nil || len(mxRecords) == 0 {
-			c.JSON(400, gin.H{"error": "No MX records found"})
-			return
+// Read the request from the query string on GET or the JSON body
+// otherwise; query parameters fill in anything the body leaves out
+func requestEmail(c *gin.Context) (emailRequest, bool) {
+	req := emailRequest{email: c.Query("email"), mode: c.Query("mode"), depth: c.Query("depth"), verbosity: c.Query("verbosity"), verifyBase: c.Query("verify_base") == "true", consensus: c.Query("consensus") == "true", proxy: c.Query("proxy"), mailFrom: c.Query("mail_from"), helo: c.Query("helo")}
+	req.timeouts = make(map[string]string)
+	for _, name := range smtpTimeoutParams {
+		if v := c.Query(name); v != "" {
+			req.timeouts[name] = v
+		}
+	}
+	if c.Request.Method == "GET" {
+		return req, true
+	}
+	var body map[string]interface{}
+	if err := c.BindJSON(&body); err != nil {
+		return req, false
+	}
+	req.email, _ = body["email"].(string)
+	if mode, _ := body["mode"].(string); mode != "" {
+		req.mode = mode
+	}
+	if v, _ := body["verbosity"].(string); v != "" {
+		req.verbosity = v
+	}
+	if v, _ := body["depth"].(string); v != "" {
+		req.depth = v
+	}
+	if v, ok := body["verify_base"].(bool); ok {
+		req.verifyBase = v
+	}
+	if v, ok := body["consensus"].(bool); ok {
+		req.consensus = v
+	}
+	if v, _ := body["proxy"].(string); v != "" {
+		req.proxy = v
+	}
+	if v, _ := body["mail_from"].(string); v != "" {
+		req.mailFrom = v
+	}
+	if v, _ := body["helo"].(string); v != "" {
+		req.helo = v
+	}
+	for _, name := range smtpTimeoutParams {
+		if v, _ := body[name].(string); v != "" {
+			req.timeouts[name] = v
 		}
+	}
+	return req, true
+}
 
-		mxHost := strings.TrimSuffix(mxRecords[0].Host, ".")
-		mailFrom := "rmtomal@tm71.top"
+// Check email through the cache, or its base address when verifyBase is set
+// and it carries a +tag. The result still describes email, with
+// base_verified marking a verdict that belongs to base_email.
+// Only full-depth results with the configured identity go through the
+// cache, so a shallow one never stands in for a full check.
+func checkRequested(ctx context.Context, email string, verifyBase bool, opts checkOptions) (int, gin.H) {
+	probe := email
+	if base, _, tagged := splitSubaddress(email); tagged && verifyBase {
+		probe = base
+	}
+	check := func() (int, gin.H) { return checkEmail(ctx, probe, opts) }
+	var status int
+	var res gin.H
+	if opts.depth == depthDeep && !opts.consensus && opts.identity == (smtpIdentity{}) {
+		status, res = cachedCheck(ctx, probe, check)
+	} else {
+		started := time.Now()
+		status, res = check()
+		status, res = serveCheck(ctx, probe, started, status, res)
+	}
+	res = addressFields(ctx, email, res)
+	res["base_verified"] = probe != email
+	return status, res
+}
+
+// GET /email-check?email=... or POST /email-check {"email": "..."}
+//
+// mode=syntax only parses the address, without DNS or SMTP; verbosity picks
+// how much of the result is returned.
+func emailCheckHandler(c *gin.Context) {
+	req, ok := requestEmail(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	if req.mode == "syntax" {
+		respond(c, 200, validateSyntax(req.email))
+		return
+	}
+	if !validVerbosity(req.verbosity) {
+		c.JSON(400, gin.H{"error": apiError("invalid_verbosity", "Invalid verbosity, use minimal, standard or debug")})
+		return
+	}
+	if !validDepth(req.depth) {
+		c.JSON(400, gin.H{"error": apiError("invalid_depth", "Invalid depth, use syntax, dns, smtp or deep")})
+		return
+	}
+	timeouts, ok := req.smtpTimeouts()
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_timeout", "Invalid timeout, use a duration such as 10s"), "max": maxSMTPTimeout.String()})
+		return
+	}
 
-		results := make(chan smtpResult, 2)
+	if _, ok := findProxy(req.proxy); req.proxy != "" && !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_proxy", "Unknown proxy")})
+		return
+	}
+	if req.mailFrom != "" && !validSender(req.mailFrom) {
+		c.JSON(400, gin.H{"error": apiError("invalid_mail_from", "Invalid mail_from, use an email address or <>")})
+		return
+	}
+	if req.helo != "" && !validHeloName(req.helo) {
+		c.JSON(400, gin.H{"error": apiError("invalid_helo", "Invalid helo, use a domain name or address literal")})
+		return
+	}
 
-		// Real email
-		go func() {
-			results <- smtpCheck(mxHost, mailFrom, email)
-		}()
+	email, ok := cleanEmail(req.email)
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_email", "Invalid email")})
+		return
+	}
+	if !chargeQuota(c, 1) {
+		return
+	}
+	status, res := checkRequested(c.Request.Context(), email, req.verifyBase, req.checkOptions(timeouts))
+	setCacheHeaders(c, res)
+	respond(c, status, applyVerbosity(res, req.verbosity))
+}
 
-		// Fake email to detect catch-all
-		fakeEmail := fmt.Sprintf("nonexistent_%d@%s", 12345, domain)
-		go func() {
-			results <- smtpCheck(mxHost, mailFrom, fakeEmail)
-		}()
+func main() {
+	loadConfig(os.Args[1:])
+	setupLogging()
+	workers = newWorkerPool(verifyWorkers, verifyQueueSize)
+	if len(heloNames) == 0 {
+		slog.Warn("HELO_NAMES not set, using the detected host name", "helo", fallbackHeloName())
+	}
 
-		res1 := <-results
-		res2 := <-results
+	flushTraces, err := setupTracing()
+	if err != nil {
+		fatal("tracing setup failed", "error", err)
+	}
 
-		var data smtpResult
-		if res1.email != email {
-			data = res1
-			res1 = res2
-			res2 = data
+	if verifyCache, err = openResultCache(); err != nil {
+		fatal("result cache unavailable", "backend", resultCacheBackend, "error", err)
+	}
+	if historyDB != "" {
+		if history, err = openHistory(historyDB); err != nil {
+			fatal("history database unavailable", "error", err)
 		}
+	}
 
-		// Determine deliverability
-		codeParts := res1.logs["rcpt_to"]
-		code := 0
-		if len(codeParts) > 0 {
-			code, _ = strconv.Atoi(codeParts[:3])
-		}
-		isDeliverable := code == 250
-		risky := isDeliverable && strings.Contains(res2.logs["rcpt_to"], "250") // catch-all detected
-
-		c.JSON(200, gin.H{
-			"status":        smtpStatus(code),
-			"mx_host":       mxHost,
-			"logs":          res1.logs,
-			"isDeliverable": isDeliverable,
-			"risky":         risky,
-		})
+	// gRPC runs next to the REST API; GRPC_ADDR=off disables it
+	var grpcSrv *grpc.Server
+	if grpcAddr != "off" {
+		grpcSrv = startGRPC(grpcAddr)
+	}
+
+	app := gin.New()
+	app.Use(requestLogger(), gin.Recovery(), traceRequests(), limitBody(), requestDeadline())
+	app.NoRoute(func(c *gin.Context) {
+		c.JSON(404, gin.H{"error": apiError("not_found", "Route not found")})
 	})
+	app.GET("/healthz", healthzHandler)
+	app.GET("/readyz", readyzHandler)
+	app.GET("/metrics", metricsHandler)
+
+	// Per-client rate limiting is off unless RATE_LIMIT_PER_MINUTE is set;
+	// health probes and metrics above are never limited and get no headers
+	if rateLimitPerMinute > 0 {
+		app.Use(rateLimit(newRateLimiter(rateLimitPerMinute, time.Minute)))
+	}
+
+	app.GET("/openapi.json", openapiHandler)
+	app.GET("/docs", docsHandler)
+
+	admin := app.Group("/admin", requireAdmin())
+	admin.GET("/stats", adminStatsHandler)
+	admin.GET("/cache", cacheInfoHandler)
+	admin.DELETE("/cache", cachePurgeAllHandler)
+	admin.GET("/cache/emails/:email", cacheEntryHandler)
+	admin.DELETE("/cache/emails/:email", cachePurgeEmailHandler)
+	admin.DELETE("/cache/domains/:domain", cachePurgeDomainHandler)
+	admin.GET("/history/emails/:email", emailHistoryHandler)
+	admin.GET("/history/domains/:domain", domainHistoryHandler)
+	admin.GET("/history/trends", historyTrendsHandler)
+
+	// Everything below needs an API key once any are configured; the admin
+	// API above has its own token
+	if len(apiKeys) > 0 {
+		app.Use(requireAPIKey(), keyRateLimit())
+	}
+
+	v1 := app.Group("/v1", negotiable())
+	v1.POST("/verify", idempotent(), v1VerifyHandler)
+	v1.GET("/verify", v1VerifyHandler)
+
+	// Legacy route, superseded by /v1/verify
+	app.POST("/email-check", deprecatedRoute("/v1/verify"), negotiable(), idempotent(), emailCheckHandler)
+	app.GET("/email-check", deprecatedRoute("/v1/verify"), negotiable(), emailCheckHandler)
+
+	app.POST("/email-check/bulk", negotiable(), idempotent(), bulkCheckHandler)
+	app.POST("/email-check/csv", csvCheckHandler)
+	app.POST("/domain-check", domainCheckHandler)
+	app.GET("/mx/:domain", mxLookupHandler)
+	app.GET("/domain/:domain/auth", domainAuthHandler)
+	app.POST("/catch-all-check", catchAllCheckHandler)
+	app.POST("/validate-syntax", negotiable(), validateSyntaxHandler)
+	app.POST("/normalize", normalizeHandler)
+	app.POST("/smtp-probe", smtpProbeHandler)
+	app.GET("/ws", wsHandler)
+	app.POST("/graphql", graphqlHandler)
+
+	app.POST("/jobs", idempotent(), createJobHandler)
+	app.GET("/jobs/:id", jobStatusHandler)
+	app.GET("/jobs/:id/results", negotiable(), jobResultsHandler)
+	app.GET("/jobs/:id/stream", jobStreamHandler)
+	app.GET("/jobs/:id/export", jobExportHandler)
 
-	app.Run(":8080")
+	serve(app, grpcSrv, flushTraces)
 }
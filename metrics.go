@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics, served on /metrics next to the Go runtime's own
+// (go_goroutines and friends)
+var (
+	verificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emailhunting_verifications_total",
+		Help: "Verifications served, by canonical result.",
+	}, []string{"result"})
+
+	rcptRepliesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emailhunting_rcpt_replies_total",
+		Help: "Verifications served, by mail provider (platform) and reply class; rejection rate is undeliverable over all.",
+	}, []string{"provider", "reply_class"})
+
+	smtpStageSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "emailhunting_smtp_stage_duration_seconds",
+		Help:    "Time spent in each SMTP stage: throttle (waiting on per-domain caps), dial, banner, ehlo, starttls, vrfy, rset, mail_from, rcpt.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"stage"})
+
+	smtpSessionsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "emailhunting_smtp_sessions_in_flight",
+		Help: "SMTP sessions currently open.",
+	})
+
+	dnsErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emailhunting_dns_errors_total",
+		Help: "Failed DNS lookups, by record type and kind: not_found, timeout or failure.",
+	}, []string{"type", "kind"})
+
+	cacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "emailhunting_cache_requests_total",
+		Help: "Result cache lookups, by outcome: hit or miss.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	for _, status := range []string{"queued", "running", "done"} {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "emailhunting_jobs",
+			Help:        "Async jobs held, by status.",
+			ConstLabels: prometheus.Labels{"status": status},
+		}, func() float64 { return float64(jobs.count(status)) })
+	}
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "emailhunting_workers_busy",
+		Help: "Verification workers in use.",
+	}, func() float64 {
+		busy, _ := workers.load()
+		return float64(busy)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "emailhunting_workers_waiting",
+		Help: "Verifications waiting for a free worker.",
+	}, func() float64 {
+		_, waiting := workers.load()
+		return float64(waiting)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "emailhunting_mx_circuits_open",
+		Help: "MX hosts currently failed fast after repeated connection failures.",
+	}, func() float64 { return float64(openCircuits()) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "emailhunting_history_queue_depth",
+		Help: "Verification history records waiting to be written.",
+	}, func() float64 {
+		if history == nil {
+			return 0
+		}
+		return float64(len(history.records))
+	})
+}
+
+// Count a served result
+func recordResultMetrics(res gin.H) {
+	result, _ := res["result"].(string)
+	verificationsTotal.WithLabelValues(result).Inc()
+	if class, ok := res["reply_class"].(string); ok {
+		provider, _ := res["platform"].(string)
+		if provider == "" {
+			provider = "other"
+		}
+		rcptRepliesTotal.WithLabelValues(provider, class).Inc()
+	}
+}
+
+// Count a failed lookup of qtype records
+func recordDNSError(qtype string, err error) {
+	kind := "failure"
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		kind = "not_found"
+	case isTimeout(err):
+		kind = "timeout"
+	}
+	dnsErrorsTotal.WithLabelValues(qtype, kind).Inc()
+}
+
+// GET /metrics
+var metricsHandler = gin.WrapH(promhttp.Handler())
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Largest policy file read (RFC 8461 suggests 64 KiB)
+const maxMTASTSPolicySize = 64 << 10
+
+// RFC 8461 forbids following redirects when fetching the policy
+var mtastsClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Fetch and parse https://mta-sts.<domain>/.well-known/mta-sts.txt
+func fetchMTASTSPolicy(ctx context.Context, domain string) (gin.H, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", "https://mta-sts."+domain+"/.well-known/mta-sts.txt", nil)
+	resp, err := mtastsClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("policy fetch returned %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		return nil, fmt.Errorf("policy served as %q, want text/plain", ct)
+	}
+
+	policy := gin.H{"mx": []string{}}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxMTASTSPolicySize))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		switch k {
+		case "mx":
+			policy["mx"] = append(policy["mx"].([]string), strings.ToLower(v))
+		case "max_age":
+			if n, err := strconv.Atoi(v); err == nil {
+				policy["max_age"] = n
+			}
+		case "version", "mode":
+			policy[k] = v
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if policy["version"] != "STSv1" {
+		return nil, errors.New("policy has no version: STSv1 line")
+	}
+	return policy, nil
+}
+
+// MTA-STS state of a domain: the _mta-sts TXT record and, when it exists,
+// the policy it announces
+func lookupMTASTS(ctx context.Context, domain string) gin.H {
+	record, ok := lookupTXTPrefix(ctx, "_mta-sts."+domain, "v=STSv1")
+	if !ok {
+		return gin.H{"exists": false, "enforced": false}
+	}
+	res := gin.H{"exists": true, "record": record, "id": parseTags(record)["id"], "enforced": false}
+	policy, err := fetchMTASTSPolicy(ctx, domain)
+	if err != nil {
+		res["policy_error"] = err.Error()
+		return res
+	}
+	res["policy"] = policy
+	res["mode"] = policy["mode"]
+	res["enforced"] = policy["mode"] == "enforce"
+	return res
+}
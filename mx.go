@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Resolve an MX host's addresses and the PTR name of each
+func describeMXHost(ctx context.Context, host string, pref uint16) gin.H {
+	res := gin.H{"host": host, "priority": pref}
+	ips, err := lookupHost(ctx, host)
+	if err != nil {
+		res["error"] = err.Error()
+		res["addresses"] = []gin.H{}
+		return res
+	}
+	addrs := make([]gin.H, len(ips))
+	for i, ip := range ips {
+		addr := gin.H{"ip": ip}
+		if names, err := net.DefaultResolver.LookupAddr(ctx, ip); err == nil && len(names) > 0 {
+			addr["ptr"] = strings.TrimSuffix(names[0], ".")
+		}
+		addrs[i] = addr
+	}
+	res["addresses"] = addrs
+	return res
+}
+
+// RFC 7505: a lone "0 ." MX record means the domain accepts no mail at all
+var errNullMX = errors.New("domain publishes a null MX")
+
+// Body of a check at a null-MX domain: definitively undeliverable, without
+// connecting anywhere
+func nullMXResult(email string) gin.H {
+	body := gin.H{
+		"status":        "Domain accepts no mail",
+		"reason":        "null_mx",
+		"reply_class":   "undeliverable",
+		"catch_all":     nil,
+		"isDeliverable": false,
+		"risky":         false,
+	}
+	classifyAddress(body, email, "")
+	return body
+}
+
+// Status and error envelope of a failed MX lookup, telling a domain that
+// doesn't exist or has no mail servers apart from a resolver failure
+// (timeout, SERVFAIL) that is worth retrying
+func mxLookupFailure(domain string, err error) (int, gin.H) {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, errNullMX):
+		return 400, apiError("null_mx", "Domain accepts no mail")
+	case !errors.As(err, &dnsErr) || !dnsErr.IsNotFound:
+		return 503, apiError("dns_failure", "DNS lookup failed")
+	case !domainExists(domain):
+		return 400, apiError("domain_not_found", "Domain does not exist")
+	}
+	return 400, apiError("no_mx_records", "No MX records found")
+}
+
+// Whether domain exists in DNS: the resolver doesn't answer NXDOMAIN for
+// it. Assumed true when the question can't be asked.
+func domainExists(domain string) bool {
+	msg, err := dnsExchange(domain, dnsmessage.TypeSOA)
+	return err != nil || msg.RCode != dnsmessage.RCodeNameError
+}
+
+// GET /mx/:domain
+func mxLookupHandler(c *gin.Context) {
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Param("domain"))), "."))
+	if domain == "" || strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	records, err := lookupMX(c.Request.Context(), domain)
+	if err == nil && len(records) == 0 {
+		err = &net.DNSError{Err: "no MX records", Name: domain, IsNotFound: true}
+	}
+	if err != nil {
+		status, e := mxLookupFailure(domain, err)
+		if status == 400 {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": e, "domain": domain})
+		return
+	}
+
+	hosts := make([]gin.H, len(records))
+	var wg sync.WaitGroup
+	for i, r := range records {
+		host := strings.TrimSuffix(r.Host, ".")
+		if host == "" {
+			hosts[i] = gin.H{"host": ".", "priority": r.Pref, "null_mx": true}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, host string, pref uint16) {
+			defer wg.Done()
+			hosts[i] = describeMXHost(c.Request.Context(), host, pref)
+		}(i, host, r.Pref)
+	}
+	wg.Wait()
+
+	c.JSON(200, gin.H{"domain": domain, "mx": hosts})
+}
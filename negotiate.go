@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pick the response format from ?format= or, failing that, the Accept
+// header. JSON is the default; "" means the format is unsupported.
+func responseFormat(c *gin.Context) string {
+	switch f := strings.ToLower(c.Query("format")); f {
+	case "json", "xml", "csv":
+		return f
+	case "":
+	default:
+		return ""
+	}
+	switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2, "text/csv") {
+	case gin.MIMEXML, gin.MIMEXML2:
+		return "xml"
+	case "text/csv":
+		return "csv"
+	}
+	return "json"
+}
+
+// Reject an unsupported ?format= before the handler does any work
+func negotiable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if responseFormat(c) == "" {
+			c.AbortWithStatusJSON(400, gin.H{"error": apiError("unsupported_format", "Unsupported format, use json, xml or csv")})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Write body as JSON, XML or CSV depending on what the client asked for
+func respond(c *gin.Context, status int, body interface{}) {
+	format := responseFormat(c)
+	if format == "json" || format == "" {
+		c.JSON(status, body)
+		return
+	}
+
+	// Go through JSON so structs and maps share one generic shape
+	raw, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(500, gin.H{"error": apiError("internal_error", err.Error())})
+		return
+	}
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	dec.Decode(&tree)
+
+	var buf bytes.Buffer
+	if format == "xml" {
+		buf.WriteString(xml.Header)
+		enc := xml.NewEncoder(&buf)
+		writeXMLValue(enc, "response", tree)
+		enc.Flush()
+		c.Data(status, gin.MIMEXML+"; charset=utf-8", buf.Bytes())
+		return
+	}
+	writeCSVTable(&buf, tree)
+	c.Data(status, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// Encode a decoded JSON value as an element; array items become <item>
+func writeXMLValue(enc *xml.Encoder, name string, v interface{}) {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		enc.EncodeToken(start)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeXMLValue(enc, xmlName(k), v[k])
+		}
+		enc.EncodeToken(start.End())
+	case []interface{}:
+		enc.EncodeToken(start)
+		for _, item := range v {
+			writeXMLValue(enc, "item", item)
+		}
+		enc.EncodeToken(start.End())
+	case nil:
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "nil"}, Value: "true"}}
+		enc.EncodeToken(start)
+		enc.EncodeToken(start.End())
+	default:
+		enc.EncodeElement(scalarString(v), start)
+	}
+}
+
+// Make a JSON key usable as an XML element name
+func xmlName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		ok := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9')
+		if !ok {
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Flatten nested objects into dotted keys; arrays stay JSON-encoded
+func flattenInto(out map[string]string, prefix string, v interface{}) {
+	if m, ok := v.(map[string]interface{}); ok {
+		for k, child := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenInto(out, key, child)
+		}
+		return
+	}
+	out[prefix] = scalarString(v)
+}
+
+// One CSV row per entry of a "results" list, or a single row for any other
+// body, with the union of flattened keys as columns
+func writeCSVTable(buf *bytes.Buffer, tree interface{}) {
+	var items []interface{}
+	if m, ok := tree.(map[string]interface{}); ok {
+		if list, ok := m["results"].([]interface{}); ok {
+			items = list
+		}
+	}
+	if items == nil {
+		items = []interface{}{tree}
+	}
+
+	rows := make([]map[string]string, len(items))
+	seen := make(map[string]bool)
+	var columns []string
+	for i, item := range items {
+		rows[i] = make(map[string]string)
+		flattenInto(rows[i], "", item)
+		for k := range rows[i] {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	w := csv.NewWriter(buf)
+	w.Write(columns)
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, col := range columns {
+			record[i] = row[col]
+		}
+		w.Write(record)
+	}
+	w.Flush()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/idna"
+)
+
+// Domains where dots in the local part are ignored and +tags are delivered
+// to the base mailbox
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// Simplest form of a local part (RFC 5321 4.1.2): a quoted string whose
+// content is a plain dot-atom drops its quotes ("john.doe" -> john.doe),
+// any other quoted string is re-quoted escaping only '"' and '\'. Quoted
+// content keeps its case; unquoted local parts are lowercased.
+func canonicalLocalPart(local string) string {
+	if !strings.HasPrefix(local, `"`) || checkLocalPartSyntax(local) != "" {
+		return strings.ToLower(local)
+	}
+	var content strings.Builder
+	inner := local[1 : len(local)-1]
+	for i := 0; i < len(inner); i++ {
+		if inner[i] == '\\' {
+			i++
+		}
+		content.WriteByte(inner[i])
+	}
+	plain := content.String()
+	if plain != "" && !strings.HasPrefix(plain, `"`) && checkLocalPartSyntax(plain) == "" {
+		return strings.ToLower(plain)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(plain) + `"`
+}
+
+// Drop the dots from the local part at a domain Gmail serves, which ignores
+// them: gmail.com, googlemail.com and Google Workspace domains
+func foldGmailDots(ctx context.Context, email string) string {
+	at := strings.LastIndex(email, "@")
+	local := email[:at]
+	if !strings.Contains(local, ".") || strings.HasPrefix(local, `"`) || !googleMailDomain(ctx, email[at+1:]) {
+		return email
+	}
+	return strings.ReplaceAll(local, ".", "") + email[at:]
+}
+
+// Report whether Gmail serves domain: gmail.com and googlemail.com, or a
+// Google Workspace domain, told by an MX under google.com or googlemail.com
+func googleMailDomain(ctx context.Context, domain string) bool {
+	domain = strings.TrimSuffix(domain, ".")
+	if gmailDomains[domain] {
+		return true
+	}
+	records, _ := lookupMX(ctx, asciiDomain(domain))
+	for _, mx := range records {
+		host := strings.ToLower(strings.TrimSuffix(mx.Host, "."))
+		if strings.HasSuffix(host, ".google.com") || strings.HasSuffix(host, ".googlemail.com") {
+			return true
+		}
+	}
+	return false
+}
+
+// Lowercase and trim an address and convert its domain to ASCII (punycode).
+// Dots are kept so /normalize can list the variants it folded together.
+func normalizeAddress(raw string) (string, bool) {
+	email, ok := cleanEmail(raw)
+	if !ok {
+		return email, false
+	}
+	at := strings.LastIndex(email, "@")
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(email[at+1:], "."))
+	if err != nil {
+		return email, false
+	}
+	return email[:at+1] + domain, true
+}
+
+// ASCII (punycode) form of a domain, as used for DNS and SMTP; the domain
+// itself when it can't be converted
+func asciiDomain(domain string) string {
+	if ascii, err := idna.Lookup.ToASCII(domain); err == nil {
+		return ascii
+	}
+	return domain
+}
+
+// Unicode form of a domain, for display
+func unicodeDomain(domain string) string {
+	if u, err := idna.Lookup.ToUnicode(domain); err == nil {
+		return u
+	}
+	return domain
+}
+
+// Key under which variants of the same mailbox collapse; gmail addresses
+// lose their dots and +tag and googlemail.com folds into gmail.com
+func mailboxKey(email string) string {
+	at := strings.LastIndex(email, "@")
+	local, domain := email[:at], email[at+1:]
+	if !gmailDomains[domain] || strings.HasPrefix(local, `"`) {
+		return email
+	}
+	local, _, _ = strings.Cut(local, "+")
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
+
+// Split a +tag off an address: the base address, the tag and whether there
+// was one. Quoted local parts are taken literally.
+func splitSubaddress(email string) (string, string, bool) {
+	at := strings.LastIndex(email, "@")
+	local := email[:at]
+	plus := strings.Index(local, "+")
+	if plus <= 0 || strings.HasPrefix(local, `"`) {
+		return email, "", false
+	}
+	return local[:plus] + email[at:], local[plus+1:], true
+}
+
+// Set the parsed form of the probed address on a result body; spellings of
+// one Gmail mailbox share it
+func addressFields(ctx context.Context, email string, res gin.H) gin.H {
+	email = foldGmailDots(ctx, email)
+	at := strings.LastIndex(email, "@")
+	res["normalized_email"] = email
+	res["local_part"] = email[:at]
+	res["domain"] = email[at+1:]
+	res["domain_ascii"] = asciiDomain(email[at+1:])
+	res["domain_unicode"] = unicodeDomain(email[at+1:])
+	base, tag, tagged := splitSubaddress(email)
+	res["is_subaddressed"] = tagged
+	res["base_email"] = base
+	res["subaddress_tag"] = tag
+	return res
+}
+
+// POST /normalize {"emails": [...]}
+func normalizeHandler(c *gin.Context) {
+	var body struct {
+		Emails []string `json:"emails"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	if len(body.Emails) == 0 {
+		c.JSON(400, gin.H{"error": apiError("no_emails", "No emails provided")})
+		return
+	}
+	if len(body.Emails) > maxJobEmails {
+		c.JSON(400, gin.H{"error": apiError("too_many_emails", "Too many emails"), "max": maxJobEmails})
+		return
+	}
+
+	unique := []gin.H{}
+	invalid := []gin.H{}
+	seen := make(map[string]gin.H)
+	for _, raw := range body.Emails {
+		email, ok := normalizeAddress(raw)
+		if ok {
+			if syn := validateSyntax(email); !syn.Valid {
+				invalid = append(invalid, gin.H{"input": raw, "reason": syn.Reason})
+				continue
+			}
+		} else {
+			invalid = append(invalid, gin.H{"input": raw, "reason": "invalid_email"})
+			continue
+		}
+
+		key := mailboxKey(email)
+		if entry, dup := seen[key]; dup {
+			entry["count"] = entry["count"].(int) + 1
+			if variants := entry["variants"].([]string); email != entry["email"] && !slices.Contains(variants, email) {
+				entry["variants"] = append(variants, email)
+			}
+			continue
+		}
+		entry := gin.H{"email": email, "canonical": key, "count": 1, "variants": []string{}}
+		seen[key] = entry
+		unique = append(unique, entry)
+	}
+
+	c.JSON(200, gin.H{
+		"input_count":        len(body.Emails),
+		"unique_count":       len(unique),
+		"duplicates_removed": len(body.Emails) - len(unique) - len(invalid),
+		"invalid_count":      len(invalid),
+		"emails":             unique,
+		"invalid":            invalid,
+	})
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	_ "embed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Hand-maintained description of every route; update it with the handlers
+//
+//go:embed api/openapi.json
+var openapiSpec []byte
+
+//go:embed templates/docs.html
+var docsPage []byte
+
+// GET /openapi.json
+func openapiHandler(c *gin.Context) {
+	c.Data(200, "application/json", openapiSpec)
+}
+
+// GET /docs — Swagger UI for the spec above
+func docsHandler(c *gin.Context) {
+	c.Data(200, "text/html; charset=utf-8", docsPage)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// SOCKS5 proxy outbound SMTP connections can be tunneled through
+type smtpProxy struct {
+	name   string
+	dialer proxy.ContextDialer
+}
+
+// Proxies SMTP connections go through (SMTP_PROXIES), taken in turn; none
+// means connecting directly
+var smtpProxies []smtpProxy
+
+var proxyTurn atomic.Uint64
+
+// Local addresses direct SMTP connections are made from (SMTP_SOURCE_IPS);
+// none lets the system choose
+var smtpSourceIPs []net.IP
+
+// How a source address is picked (SMTP_SOURCE_IP_ROTATION): round_robin, or
+// domain to always approach a recipient domain from the same address
+var sourceIPRotation = "round_robin"
+
+var sourceIPTurn atomic.Uint64
+
+// Address family of direct connections (MX_ADDRESS_FAMILY): dual_stack races
+// IPv6 and IPv4 addresses, prefer_v4 and prefer_v6 try every address of one
+// family before falling back to the other
+var mxAddressFamily = "dual_stack"
+
+// Networks direct connections try in turn
+func dialNetworks() []string {
+	switch mxAddressFamily {
+	case "prefer_v4":
+		return []string{"tcp4", "tcp6"}
+	case "prefer_v6":
+		return []string{"tcp6", "tcp4"}
+	}
+	return []string{"tcp"}
+}
+
+// "ipv4" or "ipv6" for the address of a TCP connection
+func addressFamily(addr net.Addr) string {
+	if a, ok := addr.(*net.TCPAddr); ok && a.IP.To4() == nil {
+		return "ipv6"
+	}
+	return "ipv4"
+}
+
+// Parse comma-separated "socks5://[user:pass@]host:port" URLs, each
+// optionally named as "name=url"; unnamed ones are called by host:port
+func parseSMTPProxies(v string) error {
+	var proxies []smtpProxy
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(entry, "=")
+		if !ok || strings.Contains(name, "://") {
+			name, raw = "", entry
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme != "socks5" && u.Scheme != "socks5h" || u.Host == "" {
+			return fmt.Errorf("want socks5://[user:pass@]host:port URLs")
+		}
+		d, err := proxy.FromURL(u, &net.Dialer{})
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			name = u.Host
+		}
+		proxies = append(proxies, smtpProxy{name: name, dialer: d.(proxy.ContextDialer)})
+	}
+	smtpProxies = proxies
+	return nil
+}
+
+// Parse comma-separated source addresses, each of which must belong to
+// this host
+func parseSourceIPs(v string) error {
+	local, err := net.InterfaceAddrs()
+	if err != nil {
+		return err
+	}
+	var ips []net.IP
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("%q is not an IP address", s)
+		}
+		if !slices.ContainsFunc(local, func(a net.Addr) bool {
+			n, ok := a.(*net.IPNet)
+			return ok && n.IP.Equal(ip)
+		}) {
+			return fmt.Errorf("%s is not an address of this host", ip)
+		}
+		ips = append(ips, ip)
+	}
+	smtpSourceIPs = ips
+	return nil
+}
+
+// Source address of the next direct connection over network for a
+// recipient domain ("" when there is none), nil to let the system choose;
+// false when no configured address is of network's family
+func pickSourceIP(domain, network string) (net.IP, bool) {
+	ips := smtpSourceIPs
+	if network != "tcp" {
+		ips = slices.DeleteFunc(slices.Clone(ips), func(ip net.IP) bool {
+			return (ip.To4() != nil) != (network == "tcp4")
+		})
+	}
+	n := len(ips)
+	if n == 0 {
+		return nil, len(smtpSourceIPs) == 0
+	}
+	if sourceIPRotation == "domain" && domain != "" {
+		h := fnv.New32a()
+		h.Write([]byte(domain))
+		return ips[h.Sum32()%uint32(n)], true
+	}
+	return ips[(sourceIPTurn.Add(1)-1)%uint64(n)], true
+}
+
+// Configured proxy called name
+func findProxy(name string) (smtpProxy, bool) {
+	for _, p := range smtpProxies {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return smtpProxy{}, false
+}
+
+type proxyCtxKey struct{}
+
+// Send the SMTP connections made under ctx through the named proxy
+func withSMTPProxy(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, proxyCtxKey{}, name)
+}
+
+// Proxy for the next connection: the one asked for under ctx, else the
+// next in turn; false when connecting directly
+func pickProxy(ctx context.Context) (smtpProxy, bool) {
+	if name, _ := ctx.Value(proxyCtxKey{}).(string); name != "" {
+		return findProxy(name)
+	}
+	if len(smtpProxies) == 0 {
+		return smtpProxy{}, false
+	}
+	return smtpProxies[(proxyTurn.Add(1)-1)%uint64(len(smtpProxies))], true
+}
+
+// Open a TCP connection to an SMTP server at addr within timeout, through
+// a proxy when any are configured, else directly over the configured address
+// families from a source address picked for the recipient domain. via names
+// the proxy used, "" when direct.
+func dialSMTP(ctx context.Context, addr, domain string, timeout time.Duration) (conn net.Conn, via string, err error) {
+	p, ok := pickProxy(ctx)
+	if !ok {
+		for _, network := range dialNetworks() {
+			dialer := net.Dialer{Timeout: timeout}
+			if publicOnly(ctx) {
+				dialer.Control = refusePrivate
+			}
+			ip, usable := pickSourceIP(domain, network)
+			if !usable {
+				continue
+			}
+			if ip != nil {
+				dialer.LocalAddr = &net.TCPAddr{IP: ip}
+			}
+			c, dialErr := dialer.DialContext(ctx, network, addr)
+			if dialErr == nil || ctx.Err() != nil {
+				return c, "", dialErr
+			}
+			// A family the host has no address in says less than a
+			// failure in the other
+			var addrErr *net.AddrError
+			if err == nil || !errors.As(dialErr, &addrErr) {
+				err = dialErr
+			}
+		}
+		if err == nil {
+			err = fmt.Errorf("no usable source address for %s", addr)
+		}
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err = p.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, p.name, fmt.Errorf("via proxy %s: %w", p.name, err)
+	}
+	return conn, p.name, nil
+}
+
+// Shared address space carved out for carrier-grade NAT (RFC 6598)
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+var errPrivateAddress = errors.New("private, loopback or link-local address")
+
+// Report whether ip is routable on the public internet: not loopback,
+// private, link-local, multicast or unspecified
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnatNet.Contains(ip)
+}
+
+// Addresses of host, failing with errPrivateAddress when any of them isn't
+// public
+func publicAddrs(ctx context.Context, host string) ([]string, error) {
+	addrs, err := lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if ip := net.ParseIP(a); ip == nil || !isPublicIP(ip) {
+			return nil, fmt.Errorf("%s resolves to %s: %w", host, a, errPrivateAddress)
+		}
+	}
+	return addrs, nil
+}
+
+// net.Dialer Control hook refusing connections to non-public addresses, so
+// a name re-resolved between the check and the dial can't slip through
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%s: %w", address, errPrivateAddress)
+	}
+	return nil
+}
+
+type publicOnlyCtxKey struct{}
+
+// Refuse direct connections to non-public addresses under ctx
+func withPublicOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, publicOnlyCtxKey{}, true)
+}
+
+func publicOnly(ctx context.Context) bool {
+	on, _ := ctx.Value(publicOnlyCtxKey{}).(bool)
+	return on
+}
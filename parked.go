@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Whether a domain points its name servers, MX or address at a parking
+// service
+func isParked(ctx context.Context, domain string, mxHosts []string) bool {
+	if slices.ContainsFunc(mxHosts, classifier.IsParkingHost) {
+		return true
+	}
+	if ns, err := lookupNS(ctx, domain); err == nil {
+		for _, n := range ns {
+			if classifier.IsParkingHost(n.Host) {
+				return true
+			}
+		}
+	}
+	ips, _ := lookupHost(ctx, domain)
+	return slices.ContainsFunc(ips, classifier.IsParkingHost)
+}
+
+// Look the domain's parking fingerprints up in the background and return a
+// func that waits for them and records is_parked on a result body
+func parkedSignal(ctx context.Context, domain string, mxHosts []string) func(body gin.H) {
+	done := make(chan struct{})
+	var parked bool
+	go func() {
+		parked = isParked(ctx, domain, mxHosts)
+		close(done)
+	}()
+	return func(body gin.H) {
+		<-done
+		body["is_parked"] = parked
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SMTP-bound work (verifications, catch-all and domain probes) running at
+// once across every request, job and stream (VERIFY_WORKERS). Each holds at
+// most one SMTP connection at a time, which bounds open sockets.
+var verifyWorkers = 64
+
+// Work allowed to wait for a free worker (VERIFY_QUEUE_SIZE); beyond that it
+// is turned away with server_busy rather than piling up goroutines
+var verifyQueueSize = 1000
+
+var errWorkersBusy = errors.New("all verification workers busy")
+
+// Fixed number of worker slots with a bounded line in front of them
+type workerPool struct {
+	slots   chan struct{}
+	waiting atomic.Int64
+	queue   int64
+}
+
+func newWorkerPool(workers, queue int) *workerPool {
+	return &workerPool{slots: make(chan struct{}, workers), queue: int64(queue)}
+}
+
+// Shared pool; main sizes it once the settings are loaded
+var workers = newWorkerPool(verifyWorkers, verifyQueueSize)
+
+// Take a worker, waiting in line while ctx lasts. The returned function
+// gives it back.
+func (p *workerPool) acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	default:
+	}
+	if p.waiting.Add(1) > p.queue {
+		p.waiting.Add(-1)
+		return nil, errWorkersBusy
+	}
+	defer p.waiting.Add(-1)
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *workerPool) release() { <-p.slots }
+
+// Workers in use and work waiting for one
+func (p *workerPool) load() (busy, waiting int) {
+	return len(p.slots), int(p.waiting.Load())
+}
+
+// Status and body of work that couldn't get a worker
+func busyResult(err error) (int, gin.H) {
+	if errors.Is(err, errWorkersBusy) {
+		return 503, gin.H{"error": apiError("server_busy", "Too many verifications in progress, retry later")}
+	}
+	return canceledResult(err)
+}
+
+// Take a worker for a request, answering it with busyResult when none can be had
+func acquireWorker(c *gin.Context) (func(), bool) {
+	release, err := workers.acquire(c.Request.Context())
+	if err != nil {
+		status, body := busyResult(err)
+		if status == 503 {
+			c.Header("Retry-After", "1")
+		}
+		c.JSON(status, body)
+		return nil, false
+	}
+	return release, true
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Strip the reply code from EHLO lines, leaving the advertised keywords
+func ehloKeywords(reply []string) []string {
+	caps := []string{}
+	for i, line := range reply {
+		if i == 0 || len(line) < 4 {
+			continue // first line is the greeting
+		}
+		caps = append(caps, line[4:])
+	}
+	return caps
+}
+
+// Outcome of a completed TLS handshake with host
+func tlsDetails(host string, conn *tls.Conn) gin.H {
+	state := conn.ConnectionState()
+	return gin.H{
+		"handshake": "ok",
+		"version":   tls.VersionName(state.Version),
+		"cipher":    tls.CipherSuiteName(state.CipherSuite),
+		"cert":      evaluateCert(host, state.PeerCertificates),
+	}
+}
+
+// Run the connection, EHLO, STARTTLS (or implicit TLS on 465) and QUIT
+// steps against one server without touching MAIL FROM or RCPT TO
+func smtpProbe(ctx context.Context, host string, port int) gin.H {
+	res := gin.H{"host": host, "port": port, "connected": false}
+	hostName := pickHeloName(ctx)
+
+	timeouts := defaultSMTPTimeouts
+	start := time.Now()
+	raw, via, err := dialSMTP(ctx, net.JoinHostPort(host, strconv.Itoa(port)), "", timeouts.dial)
+	if via != "" {
+		res["proxy"] = via
+	}
+	if err == nil && via == "" {
+		res["address_family"] = addressFamily(raw.RemoteAddr())
+		if len(smtpSourceIPs) > 0 {
+			res["source_ip"] = raw.LocalAddr().(*net.TCPAddr).IP.String()
+		}
+	}
+	if err != nil {
+		res["error"] = fmt.Sprintf("connection error: %v", err)
+		return res
+	}
+	defer raw.Close()
+	dc := newDeadlineConn(raw, timeouts, start)
+	var conn net.Conn = dc
+	reader := bufio.NewReader(conn)
+	res["connected"] = true
+
+	// Submissions (465) speaks TLS from the first byte instead of upgrading
+	// with STARTTLS
+	implicit := port == 465
+	if implicit {
+		dc.arm(timeouts.dial)
+		tlsConn := tls.Client(dc, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+		if err := tlsConn.Handshake(); err != nil {
+			res["tls"] = gin.H{"error": err.Error()}
+			return res
+		}
+		res["tls"] = tlsDetails(host, tlsConn)
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	dc.arm(timeouts.banner)
+	banner, err := readReply(reader)
+	res["banner"] = strings.Join(banner, "\n")
+	if err != nil {
+		res["error"] = fmt.Sprintf("reading banner: %v", err)
+		return res
+	}
+
+	reply, err := sendEHLO(conn, reader, hostName)
+	res["ehlo"] = strings.Join(reply, "\n")
+	res["capabilities"] = ehloKeywords(reply)
+	if err != nil {
+		if isConnDropped(err) {
+			err = fmt.Errorf("%w: %v", errBlockedAfterBanner, err)
+		}
+		res["error"] = fmt.Sprintf("EHLO: %v", err)
+		return res
+	}
+
+	offered := hasCapability(reply, "STARTTLS")
+	starttls := gin.H{"offered": offered}
+	if !implicit {
+		res["starttls"] = starttls
+	}
+	if offered && !implicit {
+		tlsConn, resp, err := startTLS(conn, reader, host)
+		starttls["response"] = resp
+		if err != nil {
+			// The session is unusable after a failed handshake
+			starttls["error"] = err.Error()
+			return res
+		}
+		if tlsConn != nil {
+			maps.Copy(starttls, tlsDetails(host, tlsConn))
+			conn = tlsConn
+			reader = bufio.NewReader(conn)
+
+			reply, err = sendEHLO(conn, reader, hostName)
+			res["capabilities_after_tls"] = ehloKeywords(reply)
+			if err != nil {
+				res["error"] = fmt.Sprintf("EHLO after TLS: %v", err)
+				return res
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintf(conn, "QUIT\r\n"); err == nil {
+		quit, _ := readReply(reader)
+		res["quit"] = strings.Join(quit, "\n")
+	}
+	return res
+}
+
+// Ports /smtp-probe may connect to: SMTP, submissions and submission
+var probePorts = []int{25, 465, 587}
+
+// POST /smtp-probe {"host": "...", "domain": "...", "port": 25, "proxy": "..."}
+func smtpProbeHandler(c *gin.Context) {
+	var body struct {
+		Domain string `json:"domain"`
+		Host   string `json:"host"`
+		Port   int    `json:"port"`
+		Proxy  string `json:"proxy"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	domain := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(body.Domain)), "."))
+	host := asciiDomain(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(body.Host)), "."))
+	if domain == "" && host == "" {
+		c.JSON(400, gin.H{"error": apiError("missing_host", "Host or domain required")})
+		return
+	}
+	if strings.Contains(domain, "@") {
+		c.JSON(400, gin.H{"error": apiError("invalid_domain", "Invalid domain")})
+		return
+	}
+	if host != "" && net.ParseIP(host) == nil && checkDomainSyntax(host) != "" {
+		c.JSON(400, gin.H{"error": apiError("invalid_host", "Invalid host")})
+		return
+	}
+	if body.Port == 0 {
+		body.Port = 25
+	}
+	if !slices.Contains(probePorts, body.Port) {
+		c.JSON(400, gin.H{"error": apiError("invalid_port", "Port must be 25, 465 or 587")})
+		return
+	}
+	ctx := c.Request.Context()
+	if body.Proxy != "" {
+		if _, ok := findProxy(body.Proxy); !ok {
+			c.JSON(400, gin.H{"error": apiError("invalid_proxy", "Unknown proxy")})
+			return
+		}
+		ctx = withSMTPProxy(ctx, body.Proxy)
+	}
+
+	// Any host may be probed, an MTA not yet published in MX included, but
+	// only at public addresses and on mail ports, so the endpoint can't be
+	// aimed at internal services. With a domain the host must be one of its
+	// MX hosts, the most preferred when none is named.
+	if domain != "" {
+		mxHosts, err := lookupMXHosts(ctx, domain)
+		if err != nil {
+			status, e := mxLookupFailure(domain, err)
+			c.JSON(status, gin.H{"error": e})
+			return
+		}
+		if host == "" {
+			host = mxHosts[0]
+		} else if !slices.ContainsFunc(mxHosts, func(mx string) bool { return strings.EqualFold(mx, host) }) {
+			c.JSON(400, gin.H{"error": apiError("host_not_mx", "Host is not an MX host of the domain")})
+			return
+		}
+	}
+	if _, err := publicAddrs(ctx, host); err != nil {
+		var dnsErr *net.DNSError
+		switch {
+		case errors.Is(err, errPrivateAddress):
+			c.JSON(400, gin.H{"error": apiError("forbidden_host", "Host resolves to a private or loopback address")})
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+			c.JSON(400, gin.H{"error": apiError("host_not_found", "Host has no addresses")})
+		default:
+			c.JSON(503, gin.H{"error": apiError("dns_failure", "DNS lookup failed")})
+		}
+		return
+	}
+
+	release, ok := acquireWorker(c)
+	if !ok {
+		return
+	}
+	defer release()
+	res := smtpProbe(withPublicOnly(ctx), host, body.Port)
+	if abandoned(c, gin.H{"stage": "smtp", "result": res}) {
+		return
+	}
+	c.JSON(200, res)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Self-signed certificate for mx.example.com
+func testCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mx.example.com"},
+		DNSNames:     []string{"mx.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSMTPProbeReportsCapabilitiesAndTLS(t *testing.T) {
+	cert := testCert(t)
+	fakeMX(t, func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("220 mx.example.com ESMTP\r\n"))
+		r.ReadString('\n') // EHLO
+		conn.Write([]byte("250-mx.example.com\r\n250-SIZE 1000\r\n250 STARTTLS\r\n"))
+		r.ReadString('\n') // STARTTLS
+		conn.Write([]byte("220 ready\r\n"))
+		tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS13})
+		if tlsConn.Handshake() != nil {
+			return
+		}
+		r = bufio.NewReader(tlsConn)
+		r.ReadString('\n') // EHLO
+		tlsConn.Write([]byte("250-mx.example.com\r\n250-SIZE 1000\r\n250 AUTH PLAIN\r\n"))
+		r.ReadString('\n') // QUIT
+		tlsConn.Write([]byte("221 bye\r\n"))
+	})
+	res := smtpProbe(context.Background(), "127.0.0.1", mxPort)
+	if res["connected"] != true || res["error"] != nil {
+		t.Fatalf("probe failed: %v", res)
+	}
+	if caps := res["capabilities"].([]string); !slices.Equal(caps, []string{"SIZE 1000", "STARTTLS"}) {
+		t.Errorf("capabilities = %q", caps)
+	}
+	if caps := res["capabilities_after_tls"].([]string); !slices.Equal(caps, []string{"SIZE 1000", "AUTH PLAIN"}) {
+		t.Errorf("capabilities_after_tls = %q", caps)
+	}
+	starttls := res["starttls"].(gin.H)
+	if starttls["offered"] != true || starttls["handshake"] != "ok" {
+		t.Errorf("starttls = %v", starttls)
+	}
+	if starttls["version"] != "TLS 1.3" {
+		t.Errorf("version = %v, want TLS 1.3", starttls["version"])
+	}
+	if cipher, _ := starttls["cipher"].(string); !strings.HasPrefix(cipher, "TLS_") {
+		t.Errorf("cipher = %v", starttls["cipher"])
+	}
+	if res["quit"] != "221 bye" {
+		t.Errorf("quit = %v", res["quit"])
+	}
+}
+
+// The probe endpoint must refuse to aim at anything but a public MX host
+// of the domain on a mail port
+func TestSMTPProbeHandlerRefusesTargets(t *testing.T) {
+	fakeMXRecord(t, "loopback.test", "127.0.0.1")
+	fakeMXRecord(t, "private.test", "10.1.2.3")
+	for _, tc := range []struct {
+		body, code string
+	}{
+		{`{"domain": "loopback.test"}`, "forbidden_host"},
+		{`{"domain": "private.test", "port": 587}`, "forbidden_host"},
+		{`{"domain": "private.test", "host": "10.9.9.9"}`, "host_not_mx"},
+		{`{"domain": "private.test", "port": 22}`, "invalid_port"},
+		{`{"domain": "private.test", "port": 6379}`, "invalid_port"},
+		{`{"host": "127.0.0.1"}`, "forbidden_host"},
+		{`{"host": "10.9.9.9", "port": 465}`, "forbidden_host"},
+		{`{"host": "bad host"}`, "invalid_host"},
+		{`{}`, "missing_host"},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/smtp-probe", strings.NewReader(tc.body))
+		smtpProbeHandler(c)
+		var got struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if w.Code != 400 || got.Error.Code != tc.code {
+			t.Errorf("%s: %d %s, want 400 %s", tc.body, w.Code, w.Body, tc.code)
+		}
+	}
+}
+
+func TestPublicOnlyDialRefusesLoopback(t *testing.T) {
+	ctx := withPublicOnly(context.Background())
+	_, _, err := dialSMTP(ctx, net.JoinHostPort("127.0.0.1", "25"), "", time.Second)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("err = %v, want errPrivateAddress", err)
+	}
+}
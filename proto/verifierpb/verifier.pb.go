@@ -0,0 +1,1540 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.28.3
+// source: verifier.proto
+
+package verifierpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type VerifyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Caller-chosen correlation id, echoed in the response.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email         string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_verifier_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *VerifyRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type VerifyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Normalized address that was checked.
+	Email         string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Status        string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	MxHost        string `protobuf:"bytes,4,opt,name=mx_host,json=mxHost,proto3" json:"mx_host,omitempty"`
+	Platform      string `protobuf:"bytes,5,opt,name=platform,proto3" json:"platform,omitempty"`
+	IsDeliverable bool   `protobuf:"varint,6,opt,name=is_deliverable,json=isDeliverable,proto3" json:"is_deliverable,omitempty"`
+	// Set when the domain accepts any recipient (catch-all).
+	Risky            bool   `protobuf:"varint,7,opt,name=risky,proto3" json:"risky,omitempty"`
+	Hint             string `protobuf:"bytes,9,opt,name=hint,proto3" json:"hint,omitempty"`
+	FromCache        bool   `protobuf:"varint,10,opt,name=from_cache,json=fromCache,proto3" json:"from_cache,omitempty"`
+	CachedAgeSeconds int64  `protobuf:"varint,11,opt,name=cached_age_seconds,json=cachedAgeSeconds,proto3" json:"cached_age_seconds,omitempty"`
+	// Set instead of the fields above when the address could not be checked.
+	Error string       `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	Smtp  *SmtpSession `protobuf:"bytes,13,opt,name=smtp,proto3" json:"smtp,omitempty"`
+	Rcpt  *RcptReply   `protobuf:"bytes,14,opt,name=rcpt,proto3" json:"rcpt,omitempty"`
+	// Machine-readable outcome, e.g. "accepted", "rejected", "accept_all".
+	Reason string `protobuf:"bytes,15,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Whether the domain accepted a made-up recipient; unset when unknown.
+	CatchAll *bool `protobuf:"varint,16,opt,name=catch_all,json=catchAll,proto3,oneof" json:"catch_all,omitempty"`
+	// Likely intended address when the domain looks misspelt.
+	Suggestion string `protobuf:"bytes,17,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	// Address as probed after trimming, lowercasing and dropping the dots of
+	// a Gmail local part, and its parts.
+	NormalizedEmail string `protobuf:"bytes,18,opt,name=normalized_email,json=normalizedEmail,proto3" json:"normalized_email,omitempty"`
+	LocalPart       string `protobuf:"bytes,19,opt,name=local_part,json=localPart,proto3" json:"local_part,omitempty"`
+	Domain          string `protobuf:"bytes,20,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Whether the local part carries a +tag.
+	IsSubaddressed bool `protobuf:"varint,21,opt,name=is_subaddressed,json=isSubaddressed,proto3" json:"is_subaddressed,omitempty"`
+	// Machine-readable code for error, e.g. "no_mx_records".
+	ErrorCode string `protobuf:"bytes,22,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	// Domain belongs to a temporary-mail provider.
+	IsDisposable bool `protobuf:"varint,23,opt,name=is_disposable,json=isDisposable,proto3" json:"is_disposable,omitempty"`
+	// Local part addresses a function (info@, sales@) rather than a person.
+	IsRoleAccount bool `protobuf:"varint,24,opt,name=is_role_account,json=isRoleAccount,proto3" json:"is_role_account,omitempty"`
+	// Consumer mailbox provider (Gmail, Outlook.com, Yahoo, ...), by domain
+	// list or MX fingerprint.
+	IsFreeProvider bool `protobuf:"varint,25,opt,name=is_free_provider,json=isFreeProvider,proto3" json:"is_free_provider,omitempty"`
+	// Gravatar has an avatar for the address; unset when not checked.
+	HasGravatar *bool        `protobuf:"varint,26,opt,name=has_gravatar,json=hasGravatar,proto3,oneof" json:"has_gravatar,omitempty"`
+	Spf         *SpfRecord   `protobuf:"bytes,27,opt,name=spf,proto3" json:"spf,omitempty"`
+	Dmarc       *DmarcPolicy `protobuf:"bytes,28,opt,name=dmarc,proto3" json:"dmarc,omitempty"`
+	Dkim        *DkimKeys    `protobuf:"bytes,29,opt,name=dkim,proto3" json:"dkim,omitempty"`
+	MtaSts      *MtaSts      `protobuf:"bytes,30,opt,name=mta_sts,json=mtaSts,proto3" json:"mta_sts,omitempty"`
+	// STARTTLS certificate of the MX against its TLSA records: "valid",
+	// "invalid" or "absent"; empty when the lookup failed.
+	Dane string `protobuf:"bytes,31,opt,name=dane,proto3" json:"dane,omitempty"`
+	// DNS blacklists listing an address of the MX host.
+	BlacklistedOn []string `protobuf:"bytes,32,rep,name=blacklisted_on,json=blacklistedOn,proto3" json:"blacklisted_on,omitempty"`
+	// The one verdict to act on: "deliverable", "undeliverable", "risky",
+	// "accept_all", "disposable", "role", "unknown", "invalid_syntax" or
+	// "dns_error".
+	Result string `protobuf:"bytes,33,opt,name=result,proto3" json:"result,omitempty"`
+	// 0-100 delivery confidence from all signals, see SCORE_WEIGHTS.
+	Score int32 `protobuf:"varint,34,opt,name=score,proto3" json:"score,omitempty"`
+	// VRFY/EXPN answer for the address, with VRFY_CHECK=on.
+	Vrfy *VrfyReply `protobuf:"bytes,35,opt,name=vrfy,proto3" json:"vrfy,omitempty"`
+	// Platform rule that adjusted the verdict, e.g. Yahoo accepting any
+	// recipient.
+	Heuristic string `protobuf:"bytes,36,opt,name=heuristic,proto3" json:"heuristic,omitempty"`
+	// Domain in punycode (as used for DNS and SMTP) and in Unicode.
+	DomainAscii   string `protobuf:"bytes,37,opt,name=domain_ascii,json=domainAscii,proto3" json:"domain_ascii,omitempty"`
+	DomainUnicode string `protobuf:"bytes,38,opt,name=domain_unicode,json=domainUnicode,proto3" json:"domain_unicode,omitempty"`
+	// Address without its +tag, and the tag itself ("" when untagged).
+	BaseEmail     string `protobuf:"bytes,39,opt,name=base_email,json=baseEmail,proto3" json:"base_email,omitempty"`
+	SubaddressTag string `protobuf:"bytes,40,opt,name=subaddress_tag,json=subaddressTag,proto3" json:"subaddress_tag,omitempty"`
+	// SMTP conversations tried, counting retries of transient failures.
+	SmtpAttempts int32 `protobuf:"varint,41,opt,name=smtp_attempts,json=smtpAttempts,proto3" json:"smtp_attempts,omitempty"`
+	// How far the check went: syntax, dns, smtp or deep.
+	Depth string `protobuf:"bytes,42,opt,name=depth,proto3" json:"depth,omitempty"`
+	// Evaluation of the certificate the MX presented during STARTTLS.
+	TlsCert *TlsCert `protobuf:"bytes,43,opt,name=tls_cert,json=tlsCert,proto3" json:"tls_cert,omitempty"`
+	// Verdict by RCPT reply family: "deliverable", "undeliverable",
+	// "temp_failure" or "unknown".
+	ReplyClass string `protobuf:"bytes,44,opt,name=reply_class,json=replyClass,proto3" json:"reply_class,omitempty"`
+	// Rough spam-trap odds, "low", "medium" or "high"; deep checks only.
+	SpamtrapRisk string `protobuf:"bytes,45,opt,name=spamtrap_risk,json=spamtrapRisk,proto3" json:"spamtrap_risk,omitempty"`
+	// Days since the domain was registered, per RDAP; unset when unknown.
+	DomainAgeDays *int32 `protobuf:"varint,46,opt,name=domain_age_days,json=domainAgeDays,proto3,oneof" json:"domain_age_days,omitempty"`
+	// NS, MX or A record points at a parking service; unset below deep.
+	IsParked *bool `protobuf:"varint,47,opt,name=is_parked,json=isParked,proto3,oneof" json:"is_parked,omitempty"`
+	// Operator rule that flagged the local part as low quality, e.g. "test".
+	LowQualityRule string `protobuf:"bytes,48,opt,name=low_quality_rule,json=lowQualityRule,proto3" json:"low_quality_rule,omitempty"`
+	// Mail to the domain draws complaints or blocklistings (toxic.txt).
+	IsToxic bool `protobuf:"varint,49,opt,name=is_toxic,json=isToxic,proto3" json:"is_toxic,omitempty"`
+	// Answer of a second MX host, when a consensus check was asked for.
+	Consensus *Consensus `protobuf:"bytes,51,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	// Why the address failed the syntax check.
+	SyntaxError string `protobuf:"bytes,52,opt,name=syntax_error,json=syntaxError,proto3" json:"syntax_error,omitempty"`
+	// The verdict is that of base_email, the +tag having been dropped.
+	BaseVerified  bool `protobuf:"varint,53,opt,name=base_verified,json=baseVerified,proto3" json:"base_verified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_verifier_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VerifyResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetMxHost() string {
+	if x != nil {
+		return x.MxHost
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetIsDeliverable() bool {
+	if x != nil {
+		return x.IsDeliverable
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetRisky() bool {
+	if x != nil {
+		return x.Risky
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetHint() string {
+	if x != nil {
+		return x.Hint
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetFromCache() bool {
+	if x != nil {
+		return x.FromCache
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetCachedAgeSeconds() int64 {
+	if x != nil {
+		return x.CachedAgeSeconds
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetSmtp() *SmtpSession {
+	if x != nil {
+		return x.Smtp
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetRcpt() *RcptReply {
+	if x != nil {
+		return x.Rcpt
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetCatchAll() bool {
+	if x != nil && x.CatchAll != nil {
+		return *x.CatchAll
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetNormalizedEmail() string {
+	if x != nil {
+		return x.NormalizedEmail
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetLocalPart() string {
+	if x != nil {
+		return x.LocalPart
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetIsSubaddressed() bool {
+	if x != nil {
+		return x.IsSubaddressed
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetIsDisposable() bool {
+	if x != nil {
+		return x.IsDisposable
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetIsRoleAccount() bool {
+	if x != nil {
+		return x.IsRoleAccount
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetIsFreeProvider() bool {
+	if x != nil {
+		return x.IsFreeProvider
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetHasGravatar() bool {
+	if x != nil && x.HasGravatar != nil {
+		return *x.HasGravatar
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetSpf() *SpfRecord {
+	if x != nil {
+		return x.Spf
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetDmarc() *DmarcPolicy {
+	if x != nil {
+		return x.Dmarc
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetDkim() *DkimKeys {
+	if x != nil {
+		return x.Dkim
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetMtaSts() *MtaSts {
+	if x != nil {
+		return x.MtaSts
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetDane() string {
+	if x != nil {
+		return x.Dane
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetBlacklistedOn() []string {
+	if x != nil {
+		return x.BlacklistedOn
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetVrfy() *VrfyReply {
+	if x != nil {
+		return x.Vrfy
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetHeuristic() string {
+	if x != nil {
+		return x.Heuristic
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetDomainAscii() string {
+	if x != nil {
+		return x.DomainAscii
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetDomainUnicode() string {
+	if x != nil {
+		return x.DomainUnicode
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetBaseEmail() string {
+	if x != nil {
+		return x.BaseEmail
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetSubaddressTag() string {
+	if x != nil {
+		return x.SubaddressTag
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetSmtpAttempts() int32 {
+	if x != nil {
+		return x.SmtpAttempts
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetDepth() string {
+	if x != nil {
+		return x.Depth
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetTlsCert() *TlsCert {
+	if x != nil {
+		return x.TlsCert
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetReplyClass() string {
+	if x != nil {
+		return x.ReplyClass
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetSpamtrapRisk() string {
+	if x != nil {
+		return x.SpamtrapRisk
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetDomainAgeDays() int32 {
+	if x != nil && x.DomainAgeDays != nil {
+		return *x.DomainAgeDays
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetIsParked() bool {
+	if x != nil && x.IsParked != nil {
+		return *x.IsParked
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetLowQualityRule() string {
+	if x != nil {
+		return x.LowQualityRule
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetIsToxic() bool {
+	if x != nil {
+		return x.IsToxic
+	}
+	return false
+}
+
+func (x *VerifyResponse) GetConsensus() *Consensus {
+	if x != nil {
+		return x.Consensus
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetSyntaxError() string {
+	if x != nil {
+		return x.SyntaxError
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetBaseVerified() bool {
+	if x != nil {
+		return x.BaseVerified
+	}
+	return false
+}
+
+type Consensus struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	MxHost string                 `protobuf:"bytes,1,opt,name=mx_host,json=mxHost,proto3" json:"mx_host,omitempty"`
+	// 0 when the second host didn't answer.
+	Code int32 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Unset without a second answer.
+	Agrees        *bool `protobuf:"varint,3,opt,name=agrees,proto3,oneof" json:"agrees,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Consensus) Reset() {
+	*x = Consensus{}
+	mi := &file_verifier_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Consensus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Consensus) ProtoMessage() {}
+
+func (x *Consensus) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Consensus.ProtoReflect.Descriptor instead.
+func (*Consensus) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Consensus) GetMxHost() string {
+	if x != nil {
+		return x.MxHost
+	}
+	return ""
+}
+
+func (x *Consensus) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Consensus) GetAgrees() bool {
+	if x != nil && x.Agrees != nil {
+		return *x.Agrees
+	}
+	return false
+}
+
+type SpfRecord struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Exists bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	Record string                 `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	// Qualified "all" term, e.g. "-all" or "~all"; empty when missing.
+	All string `protobuf:"bytes,3,opt,name=all,proto3" json:"all,omitempty"`
+	// The record ends in -all.
+	Strict        bool     `protobuf:"varint,4,opt,name=strict,proto3" json:"strict,omitempty"`
+	Includes      []string `protobuf:"bytes,5,rep,name=includes,proto3" json:"includes,omitempty"`
+	Ips           []string `protobuf:"bytes,6,rep,name=ips,proto3" json:"ips,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpfRecord) Reset() {
+	*x = SpfRecord{}
+	mi := &file_verifier_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpfRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpfRecord) ProtoMessage() {}
+
+func (x *SpfRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpfRecord.ProtoReflect.Descriptor instead.
+func (*SpfRecord) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SpfRecord) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *SpfRecord) GetRecord() string {
+	if x != nil {
+		return x.Record
+	}
+	return ""
+}
+
+func (x *SpfRecord) GetAll() string {
+	if x != nil {
+		return x.All
+	}
+	return ""
+}
+
+func (x *SpfRecord) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
+func (x *SpfRecord) GetIncludes() []string {
+	if x != nil {
+		return x.Includes
+	}
+	return nil
+}
+
+func (x *SpfRecord) GetIps() []string {
+	if x != nil {
+		return x.Ips
+	}
+	return nil
+}
+
+type SmtpSession struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Connected bool                   `protobuf:"varint,1,opt,name=connected,proto3" json:"connected,omitempty"`
+	Banner    string                 `protobuf:"bytes,2,opt,name=banner,proto3" json:"banner,omitempty"`
+	// "not_offered", "ok" or "failed".
+	Tls      string `protobuf:"bytes,3,opt,name=tls,proto3" json:"tls,omitempty"`
+	TlsError string `protobuf:"bytes,4,opt,name=tls_error,json=tlsError,proto3" json:"tls_error,omitempty"`
+	// 25, or 587/465 when only a submission port answered; 0 when none did.
+	Port int32 `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	// SOCKS5 proxy the session went through; empty when direct.
+	Proxy string `protobuf:"bytes,6,opt,name=proxy,proto3" json:"proxy,omitempty"`
+	// Local address of a direct session, with SMTP_SOURCE_IPS.
+	SourceIp string `protobuf:"bytes,7,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	// "ipv4" or "ipv6" for a direct session.
+	AddressFamily string `protobuf:"bytes,8,opt,name=address_family,json=addressFamily,proto3" json:"address_family,omitempty"`
+	// Name sent in EHLO and the MAIL FROM sender.
+	Helo          string `protobuf:"bytes,9,opt,name=helo,proto3" json:"helo,omitempty"`
+	MailFrom      string `protobuf:"bytes,10,opt,name=mail_from,json=mailFrom,proto3" json:"mail_from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SmtpSession) Reset() {
+	*x = SmtpSession{}
+	mi := &file_verifier_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SmtpSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SmtpSession) ProtoMessage() {}
+
+func (x *SmtpSession) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SmtpSession.ProtoReflect.Descriptor instead.
+func (*SmtpSession) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SmtpSession) GetConnected() bool {
+	if x != nil {
+		return x.Connected
+	}
+	return false
+}
+
+func (x *SmtpSession) GetBanner() string {
+	if x != nil {
+		return x.Banner
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetTls() string {
+	if x != nil {
+		return x.Tls
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetTlsError() string {
+	if x != nil {
+		return x.TlsError
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *SmtpSession) GetProxy() string {
+	if x != nil {
+		return x.Proxy
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetAddressFamily() string {
+	if x != nil {
+		return x.AddressFamily
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetHelo() string {
+	if x != nil {
+		return x.Helo
+	}
+	return ""
+}
+
+func (x *SmtpSession) GetMailFrom() string {
+	if x != nil {
+		return x.MailFrom
+	}
+	return ""
+}
+
+type DmarcPolicy struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Exists bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	Record string                 `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	// "none", "quarantine" or "reject".
+	Policy string `protobuf:"bytes,3,opt,name=policy,proto3" json:"policy,omitempty"`
+	// Alignment modes, "r" (relaxed) or "s" (strict).
+	Adkim         string `protobuf:"bytes,4,opt,name=adkim,proto3" json:"adkim,omitempty"`
+	Aspf          string `protobuf:"bytes,5,opt,name=aspf,proto3" json:"aspf,omitempty"`
+	Rua           string `protobuf:"bytes,6,opt,name=rua,proto3" json:"rua,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DmarcPolicy) Reset() {
+	*x = DmarcPolicy{}
+	mi := &file_verifier_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DmarcPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DmarcPolicy) ProtoMessage() {}
+
+func (x *DmarcPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DmarcPolicy.ProtoReflect.Descriptor instead.
+func (*DmarcPolicy) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DmarcPolicy) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *DmarcPolicy) GetRecord() string {
+	if x != nil {
+		return x.Record
+	}
+	return ""
+}
+
+func (x *DmarcPolicy) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *DmarcPolicy) GetAdkim() string {
+	if x != nil {
+		return x.Adkim
+	}
+	return ""
+}
+
+func (x *DmarcPolicy) GetAspf() string {
+	if x != nil {
+		return x.Aspf
+	}
+	return ""
+}
+
+func (x *DmarcPolicy) GetRua() string {
+	if x != nil {
+		return x.Rua
+	}
+	return ""
+}
+
+type DkimKeys struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	SelectorsChecked []string               `protobuf:"bytes,1,rep,name=selectors_checked,json=selectorsChecked,proto3" json:"selectors_checked,omitempty"`
+	// Selectors that publish a key.
+	Found         []string `protobuf:"bytes,2,rep,name=found,proto3" json:"found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DkimKeys) Reset() {
+	*x = DkimKeys{}
+	mi := &file_verifier_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DkimKeys) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DkimKeys) ProtoMessage() {}
+
+func (x *DkimKeys) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DkimKeys.ProtoReflect.Descriptor instead.
+func (*DkimKeys) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DkimKeys) GetSelectorsChecked() []string {
+	if x != nil {
+		return x.SelectorsChecked
+	}
+	return nil
+}
+
+func (x *DkimKeys) GetFound() []string {
+	if x != nil {
+		return x.Found
+	}
+	return nil
+}
+
+type TlsCert struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Trusted and matching the MX hostname.
+	Valid         bool   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Trusted       bool   `protobuf:"varint,2,opt,name=trusted,proto3" json:"trusted,omitempty"`
+	HostnameMatch bool   `protobuf:"varint,3,opt,name=hostname_match,json=hostnameMatch,proto3" json:"hostname_match,omitempty"`
+	Expired       bool   `protobuf:"varint,4,opt,name=expired,proto3" json:"expired,omitempty"`
+	SelfSigned    bool   `protobuf:"varint,5,opt,name=self_signed,json=selfSigned,proto3" json:"self_signed,omitempty"`
+	Subject       string `protobuf:"bytes,6,opt,name=subject,proto3" json:"subject,omitempty"`
+	Issuer        string `protobuf:"bytes,7,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	// RFC 3339.
+	NotAfter        string `protobuf:"bytes,8,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DaysUntilExpiry int32  `protobuf:"varint,9,opt,name=days_until_expiry,json=daysUntilExpiry,proto3" json:"days_until_expiry,omitempty"`
+	Error           string `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TlsCert) Reset() {
+	*x = TlsCert{}
+	mi := &file_verifier_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TlsCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TlsCert) ProtoMessage() {}
+
+func (x *TlsCert) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TlsCert.ProtoReflect.Descriptor instead.
+func (*TlsCert) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TlsCert) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *TlsCert) GetTrusted() bool {
+	if x != nil {
+		return x.Trusted
+	}
+	return false
+}
+
+func (x *TlsCert) GetHostnameMatch() bool {
+	if x != nil {
+		return x.HostnameMatch
+	}
+	return false
+}
+
+func (x *TlsCert) GetExpired() bool {
+	if x != nil {
+		return x.Expired
+	}
+	return false
+}
+
+func (x *TlsCert) GetSelfSigned() bool {
+	if x != nil {
+		return x.SelfSigned
+	}
+	return false
+}
+
+func (x *TlsCert) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *TlsCert) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *TlsCert) GetNotAfter() string {
+	if x != nil {
+		return x.NotAfter
+	}
+	return ""
+}
+
+func (x *TlsCert) GetDaysUntilExpiry() int32 {
+	if x != nil {
+		return x.DaysUntilExpiry
+	}
+	return 0
+}
+
+func (x *TlsCert) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type MtaSts struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Exists bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	// The policy mode is "enforce".
+	Enforced bool `protobuf:"varint,2,opt,name=enforced,proto3" json:"enforced,omitempty"`
+	// "enforce", "testing" or "none".
+	Mode string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// MX patterns allowed by the policy.
+	Mx            []string `protobuf:"bytes,4,rep,name=mx,proto3" json:"mx,omitempty"`
+	MaxAge        int64    `protobuf:"varint,5,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	PolicyError   string   `protobuf:"bytes,6,opt,name=policy_error,json=policyError,proto3" json:"policy_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MtaSts) Reset() {
+	*x = MtaSts{}
+	mi := &file_verifier_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MtaSts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MtaSts) ProtoMessage() {}
+
+func (x *MtaSts) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MtaSts.ProtoReflect.Descriptor instead.
+func (*MtaSts) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MtaSts) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *MtaSts) GetEnforced() bool {
+	if x != nil {
+		return x.Enforced
+	}
+	return false
+}
+
+func (x *MtaSts) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *MtaSts) GetMx() []string {
+	if x != nil {
+		return x.Mx
+	}
+	return nil
+}
+
+func (x *MtaSts) GetMaxAge() int64 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *MtaSts) GetPolicyError() string {
+	if x != nil {
+		return x.PolicyError
+	}
+	return ""
+}
+
+type RcptReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// RFC 3463 enhanced status code such as "5.1.1", when present.
+	EnhancedCode string `protobuf:"bytes,2,opt,name=enhanced_code,json=enhancedCode,proto3" json:"enhanced_code,omitempty"`
+	Message      string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// What enhanced_code means, e.g. "mailbox_not_found", "mailbox_full",
+	// "relay_denied".
+	EnhancedReason string `protobuf:"bytes,4,opt,name=enhanced_reason,json=enhancedReason,proto3" json:"enhanced_reason,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RcptReply) Reset() {
+	*x = RcptReply{}
+	mi := &file_verifier_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RcptReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RcptReply) ProtoMessage() {}
+
+func (x *RcptReply) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RcptReply.ProtoReflect.Descriptor instead.
+func (*RcptReply) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RcptReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *RcptReply) GetEnhancedCode() string {
+	if x != nil {
+		return x.EnhancedCode
+	}
+	return ""
+}
+
+func (x *RcptReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RcptReply) GetEnhancedReason() string {
+	if x != nil {
+		return x.EnhancedReason
+	}
+	return ""
+}
+
+type VrfyReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "VRFY" or "EXPN".
+	Command      string `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Code         int32  `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	EnhancedCode string `protobuf:"bytes,3,opt,name=enhanced_code,json=enhancedCode,proto3" json:"enhanced_code,omitempty"`
+	Message      string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// Whether the server confirmed the mailbox; unset when it wouldn't say.
+	Exists        *bool `protobuf:"varint,5,opt,name=exists,proto3,oneof" json:"exists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VrfyReply) Reset() {
+	*x = VrfyReply{}
+	mi := &file_verifier_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VrfyReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VrfyReply) ProtoMessage() {}
+
+func (x *VrfyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_verifier_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VrfyReply.ProtoReflect.Descriptor instead.
+func (*VrfyReply) Descriptor() ([]byte, []int) {
+	return file_verifier_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *VrfyReply) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *VrfyReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *VrfyReply) GetEnhancedCode() string {
+	if x != nil {
+		return x.EnhancedCode
+	}
+	return ""
+}
+
+func (x *VrfyReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *VrfyReply) GetExists() bool {
+	if x != nil && x.Exists != nil {
+		return *x.Exists
+	}
+	return false
+}
+
+var File_verifier_proto protoreflect.FileDescriptor
+
+const file_verifier_proto_rawDesc = "" +
+	"\n" +
+	"\x0everifier.proto\x12\x0femailhunting.v1\"5\n" +
+	"\rVerifyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"\xd3\x0e\n" +
+	"\x0eVerifyResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x17\n" +
+	"\amx_host\x18\x04 \x01(\tR\x06mxHost\x12\x1a\n" +
+	"\bplatform\x18\x05 \x01(\tR\bplatform\x12%\n" +
+	"\x0eis_deliverable\x18\x06 \x01(\bR\risDeliverable\x12\x14\n" +
+	"\x05risky\x18\a \x01(\bR\x05risky\x12\x12\n" +
+	"\x04hint\x18\t \x01(\tR\x04hint\x12\x1d\n" +
+	"\n" +
+	"from_cache\x18\n" +
+	" \x01(\bR\tfromCache\x12,\n" +
+	"\x12cached_age_seconds\x18\v \x01(\x03R\x10cachedAgeSeconds\x12\x14\n" +
+	"\x05error\x18\f \x01(\tR\x05error\x120\n" +
+	"\x04smtp\x18\r \x01(\v2\x1c.emailhunting.v1.SmtpSessionR\x04smtp\x12.\n" +
+	"\x04rcpt\x18\x0e \x01(\v2\x1a.emailhunting.v1.RcptReplyR\x04rcpt\x12\x16\n" +
+	"\x06reason\x18\x0f \x01(\tR\x06reason\x12 \n" +
+	"\tcatch_all\x18\x10 \x01(\bH\x00R\bcatchAll\x88\x01\x01\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\x11 \x01(\tR\n" +
+	"suggestion\x12)\n" +
+	"\x10normalized_email\x18\x12 \x01(\tR\x0fnormalizedEmail\x12\x1d\n" +
+	"\n" +
+	"local_part\x18\x13 \x01(\tR\tlocalPart\x12\x16\n" +
+	"\x06domain\x18\x14 \x01(\tR\x06domain\x12'\n" +
+	"\x0fis_subaddressed\x18\x15 \x01(\bR\x0eisSubaddressed\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x16 \x01(\tR\terrorCode\x12#\n" +
+	"\ris_disposable\x18\x17 \x01(\bR\fisDisposable\x12&\n" +
+	"\x0fis_role_account\x18\x18 \x01(\bR\risRoleAccount\x12(\n" +
+	"\x10is_free_provider\x18\x19 \x01(\bR\x0eisFreeProvider\x12&\n" +
+	"\fhas_gravatar\x18\x1a \x01(\bH\x01R\vhasGravatar\x88\x01\x01\x12,\n" +
+	"\x03spf\x18\x1b \x01(\v2\x1a.emailhunting.v1.SpfRecordR\x03spf\x122\n" +
+	"\x05dmarc\x18\x1c \x01(\v2\x1c.emailhunting.v1.DmarcPolicyR\x05dmarc\x12-\n" +
+	"\x04dkim\x18\x1d \x01(\v2\x19.emailhunting.v1.DkimKeysR\x04dkim\x120\n" +
+	"\amta_sts\x18\x1e \x01(\v2\x17.emailhunting.v1.MtaStsR\x06mtaSts\x12\x12\n" +
+	"\x04dane\x18\x1f \x01(\tR\x04dane\x12%\n" +
+	"\x0eblacklisted_on\x18  \x03(\tR\rblacklistedOn\x12\x16\n" +
+	"\x06result\x18! \x01(\tR\x06result\x12\x14\n" +
+	"\x05score\x18\" \x01(\x05R\x05score\x12.\n" +
+	"\x04vrfy\x18# \x01(\v2\x1a.emailhunting.v1.VrfyReplyR\x04vrfy\x12\x1c\n" +
+	"\theuristic\x18$ \x01(\tR\theuristic\x12!\n" +
+	"\fdomain_ascii\x18% \x01(\tR\vdomainAscii\x12%\n" +
+	"\x0edomain_unicode\x18& \x01(\tR\rdomainUnicode\x12\x1d\n" +
+	"\n" +
+	"base_email\x18' \x01(\tR\tbaseEmail\x12%\n" +
+	"\x0esubaddress_tag\x18( \x01(\tR\rsubaddressTag\x12#\n" +
+	"\rsmtp_attempts\x18) \x01(\x05R\fsmtpAttempts\x12\x14\n" +
+	"\x05depth\x18* \x01(\tR\x05depth\x123\n" +
+	"\btls_cert\x18+ \x01(\v2\x18.emailhunting.v1.TlsCertR\atlsCert\x12\x1f\n" +
+	"\vreply_class\x18, \x01(\tR\n" +
+	"replyClass\x12#\n" +
+	"\rspamtrap_risk\x18- \x01(\tR\fspamtrapRisk\x12+\n" +
+	"\x0fdomain_age_days\x18. \x01(\x05H\x02R\rdomainAgeDays\x88\x01\x01\x12 \n" +
+	"\tis_parked\x18/ \x01(\bH\x03R\bisParked\x88\x01\x01\x12(\n" +
+	"\x10low_quality_rule\x180 \x01(\tR\x0elowQualityRule\x12\x19\n" +
+	"\bis_toxic\x181 \x01(\bR\aisToxic\x128\n" +
+	"\tconsensus\x183 \x01(\v2\x1a.emailhunting.v1.ConsensusR\tconsensus\x12!\n" +
+	"\fsyntax_error\x184 \x01(\tR\vsyntaxError\x12#\n" +
+	"\rbase_verified\x185 \x01(\bR\fbaseVerifiedB\f\n" +
+	"\n" +
+	"_catch_allB\x0f\n" +
+	"\r_has_gravatarB\x12\n" +
+	"\x10_domain_age_daysB\f\n" +
+	"\n" +
+	"_is_parked\"`\n" +
+	"\tConsensus\x12\x17\n" +
+	"\amx_host\x18\x01 \x01(\tR\x06mxHost\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12\x1b\n" +
+	"\x06agrees\x18\x03 \x01(\bH\x00R\x06agrees\x88\x01\x01B\t\n" +
+	"\a_agrees\"\x93\x01\n" +
+	"\tSpfRecord\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\x12\x16\n" +
+	"\x06record\x18\x02 \x01(\tR\x06record\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\tR\x03all\x12\x16\n" +
+	"\x06strict\x18\x04 \x01(\bR\x06strict\x12\x1a\n" +
+	"\bincludes\x18\x05 \x03(\tR\bincludes\x12\x10\n" +
+	"\x03ips\x18\x06 \x03(\tR\x03ips\"\x91\x02\n" +
+	"\vSmtpSession\x12\x1c\n" +
+	"\tconnected\x18\x01 \x01(\bR\tconnected\x12\x16\n" +
+	"\x06banner\x18\x02 \x01(\tR\x06banner\x12\x10\n" +
+	"\x03tls\x18\x03 \x01(\tR\x03tls\x12\x1b\n" +
+	"\ttls_error\x18\x04 \x01(\tR\btlsError\x12\x12\n" +
+	"\x04port\x18\x05 \x01(\x05R\x04port\x12\x14\n" +
+	"\x05proxy\x18\x06 \x01(\tR\x05proxy\x12\x1b\n" +
+	"\tsource_ip\x18\a \x01(\tR\bsourceIp\x12%\n" +
+	"\x0eaddress_family\x18\b \x01(\tR\raddressFamily\x12\x12\n" +
+	"\x04helo\x18\t \x01(\tR\x04helo\x12\x1b\n" +
+	"\tmail_from\x18\n" +
+	" \x01(\tR\bmailFrom\"\x91\x01\n" +
+	"\vDmarcPolicy\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\x12\x16\n" +
+	"\x06record\x18\x02 \x01(\tR\x06record\x12\x16\n" +
+	"\x06policy\x18\x03 \x01(\tR\x06policy\x12\x14\n" +
+	"\x05adkim\x18\x04 \x01(\tR\x05adkim\x12\x12\n" +
+	"\x04aspf\x18\x05 \x01(\tR\x04aspf\x12\x10\n" +
+	"\x03rua\x18\x06 \x01(\tR\x03rua\"M\n" +
+	"\bDkimKeys\x12+\n" +
+	"\x11selectors_checked\x18\x01 \x03(\tR\x10selectorsChecked\x12\x14\n" +
+	"\x05found\x18\x02 \x03(\tR\x05found\"\xac\x02\n" +
+	"\aTlsCert\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x18\n" +
+	"\atrusted\x18\x02 \x01(\bR\atrusted\x12%\n" +
+	"\x0ehostname_match\x18\x03 \x01(\bR\rhostnameMatch\x12\x18\n" +
+	"\aexpired\x18\x04 \x01(\bR\aexpired\x12\x1f\n" +
+	"\vself_signed\x18\x05 \x01(\bR\n" +
+	"selfSigned\x12\x18\n" +
+	"\asubject\x18\x06 \x01(\tR\asubject\x12\x16\n" +
+	"\x06issuer\x18\a \x01(\tR\x06issuer\x12\x1b\n" +
+	"\tnot_after\x18\b \x01(\tR\bnotAfter\x12*\n" +
+	"\x11days_until_expiry\x18\t \x01(\x05R\x0fdaysUntilExpiry\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\"\x9c\x01\n" +
+	"\x06MtaSts\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\x12\x1a\n" +
+	"\benforced\x18\x02 \x01(\bR\benforced\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12\x0e\n" +
+	"\x02mx\x18\x04 \x03(\tR\x02mx\x12\x17\n" +
+	"\amax_age\x18\x05 \x01(\x03R\x06maxAge\x12!\n" +
+	"\fpolicy_error\x18\x06 \x01(\tR\vpolicyError\"\x87\x01\n" +
+	"\tRcptReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12#\n" +
+	"\renhanced_code\x18\x02 \x01(\tR\fenhancedCode\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12'\n" +
+	"\x0fenhanced_reason\x18\x04 \x01(\tR\x0eenhancedReason\"\xa0\x01\n" +
+	"\tVrfyReply\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\x05R\x04code\x12#\n" +
+	"\renhanced_code\x18\x03 \x01(\tR\fenhancedCode\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1b\n" +
+	"\x06exists\x18\x05 \x01(\bH\x00R\x06exists\x88\x01\x01B\t\n" +
+	"\a_exists2\xaa\x01\n" +
+	"\bVerifier\x12I\n" +
+	"\x06Verify\x12\x1e.emailhunting.v1.VerifyRequest\x1a\x1f.emailhunting.v1.VerifyResponse\x12S\n" +
+	"\fVerifyStream\x12\x1e.emailhunting.v1.VerifyRequest\x1a\x1f.emailhunting.v1.VerifyResponse(\x010\x01B\x1fZ\x1demailhunting/proto/verifierpbb\x06proto3"
+
+var (
+	file_verifier_proto_rawDescOnce sync.Once
+	file_verifier_proto_rawDescData []byte
+)
+
+func file_verifier_proto_rawDescGZIP() []byte {
+	file_verifier_proto_rawDescOnce.Do(func() {
+		file_verifier_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_verifier_proto_rawDesc), len(file_verifier_proto_rawDesc)))
+	})
+	return file_verifier_proto_rawDescData
+}
+
+var file_verifier_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_verifier_proto_goTypes = []any{
+	(*VerifyRequest)(nil),  // 0: emailhunting.v1.VerifyRequest
+	(*VerifyResponse)(nil), // 1: emailhunting.v1.VerifyResponse
+	(*Consensus)(nil),      // 2: emailhunting.v1.Consensus
+	(*SpfRecord)(nil),      // 3: emailhunting.v1.SpfRecord
+	(*SmtpSession)(nil),    // 4: emailhunting.v1.SmtpSession
+	(*DmarcPolicy)(nil),    // 5: emailhunting.v1.DmarcPolicy
+	(*DkimKeys)(nil),       // 6: emailhunting.v1.DkimKeys
+	(*TlsCert)(nil),        // 7: emailhunting.v1.TlsCert
+	(*MtaSts)(nil),         // 8: emailhunting.v1.MtaSts
+	(*RcptReply)(nil),      // 9: emailhunting.v1.RcptReply
+	(*VrfyReply)(nil),      // 10: emailhunting.v1.VrfyReply
+}
+var file_verifier_proto_depIdxs = []int32{
+	4,  // 0: emailhunting.v1.VerifyResponse.smtp:type_name -> emailhunting.v1.SmtpSession
+	9,  // 1: emailhunting.v1.VerifyResponse.rcpt:type_name -> emailhunting.v1.RcptReply
+	3,  // 2: emailhunting.v1.VerifyResponse.spf:type_name -> emailhunting.v1.SpfRecord
+	5,  // 3: emailhunting.v1.VerifyResponse.dmarc:type_name -> emailhunting.v1.DmarcPolicy
+	6,  // 4: emailhunting.v1.VerifyResponse.dkim:type_name -> emailhunting.v1.DkimKeys
+	8,  // 5: emailhunting.v1.VerifyResponse.mta_sts:type_name -> emailhunting.v1.MtaSts
+	10, // 6: emailhunting.v1.VerifyResponse.vrfy:type_name -> emailhunting.v1.VrfyReply
+	7,  // 7: emailhunting.v1.VerifyResponse.tls_cert:type_name -> emailhunting.v1.TlsCert
+	2,  // 8: emailhunting.v1.VerifyResponse.consensus:type_name -> emailhunting.v1.Consensus
+	0,  // 9: emailhunting.v1.Verifier.Verify:input_type -> emailhunting.v1.VerifyRequest
+	0,  // 10: emailhunting.v1.Verifier.VerifyStream:input_type -> emailhunting.v1.VerifyRequest
+	1,  // 11: emailhunting.v1.Verifier.Verify:output_type -> emailhunting.v1.VerifyResponse
+	1,  // 12: emailhunting.v1.Verifier.VerifyStream:output_type -> emailhunting.v1.VerifyResponse
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_verifier_proto_init() }
+func file_verifier_proto_init() {
+	if File_verifier_proto != nil {
+		return
+	}
+	file_verifier_proto_msgTypes[1].OneofWrappers = []any{}
+	file_verifier_proto_msgTypes[2].OneofWrappers = []any{}
+	file_verifier_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_verifier_proto_rawDesc), len(file_verifier_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_verifier_proto_goTypes,
+		DependencyIndexes: file_verifier_proto_depIdxs,
+		MessageInfos:      file_verifier_proto_msgTypes,
+	}.Build()
+	File_verifier_proto = out.File
+	file_verifier_proto_goTypes = nil
+	file_verifier_proto_depIdxs = nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Per-key limits by key name: requests per minute (API_KEY_RATE_LIMITS) and
+// verifications per calendar month, UTC (API_KEY_MONTHLY_QUOTAS). "*" covers
+// keys without an entry of their own; no entry means unlimited.
+var (
+	keyRateLimits    = make(map[string]int)
+	keyMonthlyQuotas = make(map[string]int)
+)
+
+// Requests per minute by key name, shared by HTTP and gRPC
+var keyLimiter = newRateLimiter(0, time.Minute)
+
+// Parse "name=N" pairs, comma-separated, into limits
+func keyLimitsSetting(limits map[string]int) func(string) error {
+	return func(v string) error {
+		for _, pair := range strings.Split(v, ",") {
+			name, n, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			parsed, err := strconv.Atoi(strings.TrimSpace(n))
+			if !ok || name == "" || err != nil || parsed < 1 {
+				return fmt.Errorf("want name=N pairs with N of at least 1")
+			}
+			limits[strings.TrimSpace(name)] = parsed
+		}
+		return nil
+	}
+}
+
+// Limit of a key, 0 for unlimited and for requests without a key
+func keyLimit(limits map[string]int, name string) int {
+	if name == "" {
+		return 0
+	}
+	if n, ok := limits[name]; ok {
+		return n
+	}
+	return limits["*"]
+}
+
+// Verifications charged to each key in the current month
+type quotaStore struct {
+	mu    sync.Mutex
+	month string
+	used  map[string]int
+}
+
+var quotas = &quotaStore{used: make(map[string]int)}
+
+// Time left until the quotas start over on the first of next month
+func quotaReset(now time.Time) time.Duration {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now)
+}
+
+// Charge n verifications to a key, returning its quota, what remains of it
+// and whether the n fit. Requests that don't fit are not charged at all.
+// quota is 0 when the key is unlimited.
+func (q *quotaStore) charge(name string, n int) (quota, remaining int, ok bool) {
+	quota = keyLimit(keyMonthlyQuotas, name)
+	if quota == 0 {
+		return 0, 0, true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if month := time.Now().UTC().Format("2006-01"); month != q.month {
+		q.month, q.used = month, make(map[string]int)
+	}
+	if q.used[name]+n > quota {
+		return quota, quota - q.used[name], false
+	}
+	q.used[name] += n
+	return quota, quota - q.used[name], true
+}
+
+// This month's usage of every key that has a quota or used one
+func (q *quotaStore) snapshot() gin.H {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	month := time.Now().UTC().Format("2006-01")
+	out := gin.H{}
+	for _, name := range apiKeys {
+		used := 0
+		if q.month == month {
+			used = q.used[name]
+		}
+		usage := gin.H{"used": used, "monthly_quota": nil}
+		if quota := keyLimit(keyMonthlyQuotas, name); quota > 0 {
+			usage["monthly_quota"] = quota
+		}
+		out[name] = usage
+	}
+	return out
+}
+
+// Charge n verifications to the caller's key, answering 429 quota_exceeded
+// when they don't fit. Quota headers go on every charged response.
+func chargeQuota(c *gin.Context, n int) bool {
+	quota, remaining, ok := quotas.charge(apiKeyName(c), n)
+	if quota == 0 {
+		return true
+	}
+	resetSecs := strconv.Itoa(int((quotaReset(time.Now()) + time.Second - 1) / time.Second))
+	c.Header("X-Quota-Limit", strconv.Itoa(quota))
+	c.Header("X-Quota-Remaining", strconv.Itoa(remaining))
+	c.Header("X-Quota-Reset", resetSecs)
+	if !ok {
+		c.Header("Retry-After", resetSecs)
+		c.JSON(429, gin.H{"error": apiError("quota_exceeded", "Monthly verification quota exceeded"), "remaining": remaining})
+	}
+	return ok
+}
+
+// Charge one verification to the key of a WebSocket, gRPC or GraphQL call,
+// returning the failed result body when the quota is used up
+func chargeQuotaCtx(ctx context.Context, email string) gin.H {
+	if _, _, ok := quotas.charge(ctxAPIKeyName(ctx), 1); !ok {
+		return gin.H{"email": email, "error": apiError("quota_exceeded", "Monthly verification quota exceeded")}
+	}
+	return nil
+}
+
+// Limit requests per API key to its API_KEY_RATE_LIMITS entry
+func keyRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := apiKeyName(c)
+		limit := keyLimit(keyRateLimits, name)
+		if limit == 0 {
+			c.Next()
+			return
+		}
+		remaining, reset, ok := keyLimiter.take(name, limit)
+		if !enforceRateLimit(c, limit, remaining, reset, ok) {
+			return
+		}
+		c.Next()
+	}
+}
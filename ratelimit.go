@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Fixed-window request counter for one client
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Per-client fixed-window rate limiter
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*rateWindow
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, clients: make(map[string]*rateWindow)}
+}
+
+// Count a request from client against limit, returning how many remain in
+// the window, when the window resets and whether the request is allowed
+func (rl *rateLimiter) take(client string, limit int) (int, time.Duration, bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	w, ok := rl.clients[client]
+	if !ok || now.Sub(w.start) >= rl.window {
+		if len(rl.clients) > 10000 {
+			for k, old := range rl.clients {
+				if now.Sub(old.start) >= rl.window {
+					delete(rl.clients, k)
+				}
+			}
+		}
+		w = &rateWindow{start: now}
+		rl.clients[client] = w
+	}
+	reset := w.start.Add(rl.window).Sub(now)
+	if w.count >= limit {
+		return 0, reset, false
+	}
+	w.count++
+	return limit - w.count, reset, true
+}
+
+// Limit requests per client IP, advertising the budget on every response
+// so clients can throttle themselves
+func rateLimit(rl *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		remaining, reset, ok := rl.take(c.ClientIP(), rl.limit)
+		if !enforceRateLimit(c, rl.limit, remaining, reset, ok) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Set the rate limit headers and abort with 429 when the request is over
+// the limit, reporting whether it may go on
+func enforceRateLimit(c *gin.Context, limit, remaining int, reset time.Duration, ok bool) bool {
+	resetSecs := strconv.Itoa(int((reset + time.Second - 1) / time.Second))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", resetSecs)
+	if remaining == 0 {
+		c.Header("Retry-After", resetSecs)
+	}
+	if !ok {
+		c.AbortWithStatusJSON(429, gin.H{"error": apiError("rate_limited", "Rate limit exceeded")})
+	}
+	return ok
+}
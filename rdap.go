@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Registration lookups run on deep checks unless RDAP_CHECK=off
+var rdapCheckEnabled = true
+
+// IANA registry of the RDAP server of each TLD (RFC 9224); only tests
+// point it elsewhere
+var rdapBootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+// How long a domain's registration date is reused (RDAP_CACHE_TTL)
+var rdapCacheTTL = 24 * time.Hour
+
+var rdapClient = &http.Client{Timeout: 10 * time.Second}
+
+// RDAP base URL by TLD, loaded from the bootstrap registry on first use
+var rdapServers = struct {
+	mu   sync.Mutex
+	urls map[string]string
+}{}
+
+type rdapEntry struct {
+	registered time.Time
+	stored     time.Time
+}
+
+// Registration dates by registrable domain
+var rdapCache = struct {
+	mu      sync.Mutex
+	entries map[string]rdapEntry
+}{entries: make(map[string]rdapEntry)}
+
+// Failing registries, and the bootstrap registry, are left alone this long
+// before being asked again, so an outage doesn't add a timeout to every
+// deep check (RDAP_FAILURE_TTL)
+var rdapFailureTTL = 5 * time.Minute
+
+// When each RDAP server (by URL) last failed
+var rdapFailures = struct {
+	mu sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// Error for a server that failed within rdapFailureTTL, nil otherwise
+func rdapBackoff(url string) error {
+	rdapFailures.mu.Lock()
+	defer rdapFailures.mu.Unlock()
+	if at, ok := rdapFailures.at[url]; ok && time.Since(at) < rdapFailureTTL {
+		return fmt.Errorf("RDAP server %s failed %s ago", url, time.Since(at).Round(time.Second))
+	}
+	return nil
+}
+
+// Note a failed request to url, unless it was the caller giving up
+func rdapFailed(ctx context.Context, url string) {
+	if ctx.Err() != nil {
+		return
+	}
+	rdapFailures.mu.Lock()
+	rdapFailures.at[url] = time.Now()
+	rdapFailures.mu.Unlock()
+}
+
+// Fetch the bootstrap registry and map each TLD to its RDAP base URL
+func fetchRDAPBootstrap(ctx context.Context) (map[string]string, error) {
+	if err := rdapBackoff(rdapBootstrapURL); err != nil {
+		return nil, err
+	}
+	req, _ := http.NewRequestWithContext(ctx, "GET", rdapBootstrapURL, nil)
+	resp, err := rdapClient.Do(req)
+	if err != nil {
+		rdapFailed(ctx, rdapBootstrapURL)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		rdapFailed(ctx, rdapBootstrapURL)
+		return nil, fmt.Errorf("RDAP bootstrap returned %s", resp.Status)
+	}
+	var registry struct {
+		Services [][][]string `json:"services"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registry); err != nil {
+		rdapFailed(ctx, rdapBootstrapURL)
+		return nil, fmt.Errorf("RDAP bootstrap: %v", err)
+	}
+	urls := make(map[string]string)
+	for _, svc := range registry.Services {
+		if len(svc) < 2 || len(svc[1]) == 0 {
+			continue
+		}
+		for _, t := range svc[0] {
+			urls[strings.ToLower(t)] = strings.TrimSuffix(svc[1][0], "/") + "/"
+		}
+	}
+	return urls, nil
+}
+
+// RDAP base URL of tld. The bootstrap registry is fetched on first use,
+// without holding the lock so a slow fetch doesn't stall other lookups; a
+// failed fetch is retried once rdapFailureTTL has passed.
+func rdapServer(ctx context.Context, tld string) (string, error) {
+	rdapServers.mu.Lock()
+	urls := rdapServers.urls
+	rdapServers.mu.Unlock()
+	if urls == nil {
+		fetched, err := fetchRDAPBootstrap(ctx)
+		if err != nil {
+			return "", err
+		}
+		rdapServers.mu.Lock()
+		if rdapServers.urls == nil {
+			rdapServers.urls = fetched
+		}
+		urls = rdapServers.urls
+		rdapServers.mu.Unlock()
+	}
+	url, ok := urls[tld]
+	if !ok {
+		return "", fmt.Errorf("no RDAP server for .%s", tld)
+	}
+	return url, nil
+}
+
+// Registration date of the registrable part of domain, from its registry's
+// RDAP server
+func lookupRegistration(ctx context.Context, domain string) (time.Time, error) {
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return time.Time{}, err
+	}
+	rdapCache.mu.Lock()
+	e, ok := rdapCache.entries[registrable]
+	rdapCache.mu.Unlock()
+	if ok && time.Since(e.stored) <= rdapCacheTTL {
+		return e.registered, nil
+	}
+
+	base, err := rdapServer(ctx, registrable[strings.LastIndex(registrable, ".")+1:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := rdapBackoff(base); err != nil {
+		return time.Time{}, err
+	}
+	req, _ := http.NewRequestWithContext(ctx, "GET", base+"domain/"+registrable, nil)
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := rdapClient.Do(req)
+	if err != nil {
+		rdapFailed(ctx, base)
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		// 404 is the registry's answer for an unknown domain, not a fault
+		if resp.StatusCode != 404 {
+			rdapFailed(ctx, base)
+		}
+		return time.Time{}, fmt.Errorf("RDAP lookup returned %s", resp.Status)
+	}
+	var record struct {
+		Events []struct {
+			Action string    `json:"eventAction"`
+			Date   time.Time `json:"eventDate"`
+		} `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&record); err != nil {
+		return time.Time{}, fmt.Errorf("RDAP response: %v", err)
+	}
+	for _, ev := range record.Events {
+		if ev.Action == "registration" {
+			rdapCache.mu.Lock()
+			rdapCache.entries[registrable] = rdapEntry{registered: ev.Date, stored: time.Now()}
+			rdapCache.mu.Unlock()
+			return ev.Date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no registration event for %s", registrable)
+}
+
+// Look up the domain's registration date in the background and return a
+// func that waits for it and records domain_age_days on a result body, nil
+// when the registry couldn't tell
+func domainAgeSignal(ctx context.Context, domain string) func(body gin.H) {
+	if !rdapCheckEnabled {
+		return func(gin.H) {}
+	}
+	done := make(chan struct{})
+	var registered time.Time
+	var err error
+	go func() {
+		registered, err = lookupRegistration(ctx, domain)
+		close(done)
+	}()
+	return func(body gin.H) {
+		<-done
+		if err != nil {
+			body["domain_age_days"] = nil
+			return
+		}
+		body["domain_age_days"] = int(time.Since(registered).Hours() / 24)
+	}
+}
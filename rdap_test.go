@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// Start an RDAP server answering every request with status, and reset the
+// registry state around the test
+func fakeRDAP(t *testing.T, status int) (url string, hits *atomic.Int32) {
+	hits = new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	reset := func() {
+		rdapServers.mu.Lock()
+		rdapServers.urls = nil
+		rdapServers.mu.Unlock()
+		rdapFailures.mu.Lock()
+		clear(rdapFailures.at)
+		rdapFailures.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+	return srv.URL + "/", hits
+}
+
+func TestRDAPFailingRegistryIsSkipped(t *testing.T) {
+	url, hits := fakeRDAP(t, 503)
+	rdapServers.urls = map[string]string{"test": url}
+	for range 3 {
+		if _, err := lookupRegistration(context.Background(), "failing.test"); err == nil {
+			t.Fatal("lookup succeeded against a failing registry")
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("registry asked %d times, want 1", n)
+	}
+}
+
+func TestRDAPUnknownDomainIsNotAFailure(t *testing.T) {
+	url, hits := fakeRDAP(t, 404)
+	rdapServers.urls = map[string]string{"test": url}
+	lookupRegistration(context.Background(), "one.test")
+	lookupRegistration(context.Background(), "two.test")
+	if n := hits.Load(); n != 2 {
+		t.Errorf("registry asked %d times, want 2", n)
+	}
+}
+
+func TestRDAPBootstrapRejectsErrorStatus(t *testing.T) {
+	url, hits := fakeRDAP(t, 500)
+	old := rdapBootstrapURL
+	rdapBootstrapURL = url
+	t.Cleanup(func() { rdapBootstrapURL = old })
+	for range 2 {
+		if _, err := rdapServer(context.Background(), "test"); err == nil {
+			t.Fatal("bootstrap accepted a 500")
+		}
+	}
+	if rdapServers.urls != nil {
+		t.Error("failed bootstrap was kept")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("bootstrap fetched %d times, want 1", n)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis server of the redis cache backend (REDIS_URL)
+var redisURL = "redis://localhost:6379/0"
+
+// Prefix of the cache's keys, so the Redis database can be shared
+const redisKeyPrefix = "emailhunting:result:"
+
+// Deadline of each cache operation; a slow Redis counts as a miss
+const redisTimeout = time.Second
+
+func init() {
+	// Types found in result bodies, so they come back out of gob as the
+	// same types the handlers assert
+	gob.Register(gin.H{})
+	gob.Register([]gin.H{})
+	gob.Register([]string{})
+	gob.Register(time.Time{})
+	gob.Register(map[string]string{})
+	gob.Register([]transcriptLine{})
+	gob.Register(emptyList{})
+}
+
+// Stand-in for an empty, non-nil list, which gob would decode as nil and
+// JSON then render as null instead of []
+type emptyList struct{ Maps bool }
+
+// Copy of body with empty lists swapped for emptyList (encode) or back
+// (decode), at any depth
+func swapEmptyLists(body gin.H, encode bool) gin.H {
+	out := make(gin.H, len(body))
+	for k, v := range body {
+		switch v := v.(type) {
+		case gin.H:
+			out[k] = swapEmptyLists(v, encode)
+		case []gin.H:
+			if encode && v != nil && len(v) == 0 {
+				out[k] = emptyList{Maps: true}
+				continue
+			}
+			list := make([]gin.H, len(v))
+			for i, m := range v {
+				list[i] = swapEmptyLists(m, encode)
+			}
+			out[k] = list
+		case []string:
+			if encode && v != nil && len(v) == 0 {
+				out[k] = emptyList{}
+			} else {
+				out[k] = v
+			}
+		case emptyList:
+			if v.Maps {
+				out[k] = []gin.H{}
+			} else {
+				out[k] = []string{}
+			}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// Verification result cache shared by every instance through Redis. Entries
+// expire on their own after the TTL.
+type redisCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// Stored form of a cache entry
+type redisEntry struct {
+	Body   gin.H
+	Stored time.Time
+}
+
+func newRedisCache(url string, ttl time.Duration) (*redisCache, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisCache{client: redis.NewClient(opts), ttl: ttl}
+	if err := rc.ping(); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+func redisContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisTimeout)
+}
+
+func (rc *redisCache) close() error {
+	return rc.client.Close()
+}
+
+func (rc *redisCache) ping() error {
+	ctx, cancel := redisContext()
+	defer cancel()
+	return rc.client.Ping(ctx).Err()
+}
+
+func (rc *redisCache) get(email string) (cacheEntry, bool) {
+	ctx, cancel := redisContext()
+	defer cancel()
+	data, err := rc.client.Get(ctx, redisKeyPrefix+email).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Warn("result cache read failed", "error", err)
+		}
+		return cacheEntry{}, false
+	}
+	var e redisEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		return cacheEntry{}, false // written by an incompatible version
+	}
+	return cacheEntry{body: swapEmptyLists(e.Body, false), stored: e.Stored}, true
+}
+
+func (rc *redisCache) set(email string, body gin.H) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(redisEntry{Body: swapEmptyLists(body, true), Stored: time.Now()}); err != nil {
+		slog.Error("result cache encoding failed", "domain", emailDomain(email), "error", err)
+		return
+	}
+	ctx, cancel := redisContext()
+	defer cancel()
+	if err := rc.client.Set(ctx, redisKeyPrefix+email, buf.Bytes(), rc.ttl).Err(); err != nil {
+		slog.Warn("result cache write failed", "error", err)
+	}
+}
+
+func (rc *redisCache) purge(email string) bool {
+	ctx, cancel := redisContext()
+	defer cancel()
+	n, err := rc.client.Del(ctx, redisKeyPrefix+email).Result()
+	return err == nil && n > 0
+}
+
+// Delete every key matching pattern, returning how many were removed
+func (rc *redisCache) deleteMatching(pattern string) int {
+	ctx := context.Background()
+	removed := 0
+	iter := rc.client.Scan(ctx, 0, pattern, 1000).Iterator()
+	var batch []string
+	flush := func() {
+		if len(batch) > 0 {
+			n, _ := rc.client.Del(ctx, batch...).Result()
+			removed += int(n)
+			batch = batch[:0]
+		}
+	}
+	for iter.Next(ctx) {
+		if batch = append(batch, iter.Val()); len(batch) == 1000 {
+			flush()
+		}
+	}
+	flush()
+	return removed
+}
+
+// Escapes glob metacharacters in a SCAN pattern
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+func (rc *redisCache) purgeDomain(domain string) int {
+	return rc.deleteMatching(redisKeyPrefix + "*@" + redisGlobEscaper.Replace(domain))
+}
+
+func (rc *redisCache) purgeAll() int {
+	return rc.deleteMatching(redisKeyPrefix + "*")
+}
+
+func (rc *redisCache) size() int {
+	ctx := context.Background()
+	n := 0
+	iter := rc.client.Scan(ctx, 0, redisKeyPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		n++
+	}
+	return n
+}
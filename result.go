@@ -0,0 +1,50 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// Values of result, in the order /admin/stats lists them
+var canonicalResults = []string{"deliverable", "undeliverable", "risky", "accept_all", "disposable", "role", "unknown", "invalid_syntax", "dns_error"}
+
+// Error codes of an MX lookup that found nothing or failed
+var dnsErrors = map[string]bool{
+	"no_mx_records":    true,
+	"domain_not_found": true,
+	"dns_failure":      true,
+}
+
+// The one verdict a client needs, folding reply_class, the address flags and
+// the catch-all finding together. Earlier cases win: a disposable address
+// that bounces is undeliverable, an accepted role address is role.
+func canonicalResult(res gin.H) string {
+	if code, _, failed := resultError(res); failed {
+		if dnsErrors[code] {
+			return "dns_error"
+		}
+		return "unknown" // canceled or timed out before a verdict
+	}
+	class, _ := res["reply_class"].(string)
+	switch {
+	case res["reason"] == "invalid_syntax":
+		return "invalid_syntax"
+	case res["reason"] == "low_quality", res["is_toxic"] == true:
+		return "risky"
+	case class == "undeliverable":
+		return "undeliverable"
+	case res["reason"] == "mx_disagreement":
+		return "unknown"
+	case res["is_parked"] == true:
+		return "risky" // something may answer, but nobody reads the mail
+	case res["is_disposable"] == true:
+		return "disposable"
+	case res["is_role_account"] == true:
+		return "role"
+	case res["reason"] == "accept_all",
+		res["catch_all"] == true && res["reason"] != "vrfy_confirmed":
+		return "accept_all"
+	case res["risky"] == true:
+		return "risky"
+	case class == "deliverable":
+		return "deliverable"
+	}
+	return "unknown" // temp failures, greylisting, no reply or a shallow depth
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Points behind the 0-100 score; SCORE_WEIGHTS overrides any of them. The
+// SMTP verdict sets the base, the other signals add to or take from it.
+var scoreWeights = map[string]int{
+	"deliverable":   80, // RCPT accepted
+	"temp_failure":  30,
+	"unknown":       20, // no usable RCPT answer
+	"undeliverable": 0,  // final, no other signal applies
+	"catch_all":     -30,
+	"disposable":    -40,
+	"toxic":         -80,
+	"role_account":  -10,
+	"spf":           10,
+	"dmarc":         10,
+	"blacklisted":   -15, // per DNSBL listing the MX
+}
+
+// Apply "name=points,..." overrides to scoreWeights
+func parseScoreWeights(raw string) error {
+	for _, pair := range strings.Split(raw, ",") {
+		name, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("%q is not name=points", pair)
+		}
+		name = strings.TrimSpace(name)
+		if _, known := scoreWeights[name]; !known {
+			return fmt.Errorf("unknown weight %q", name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("weight %q: %v", name, err)
+		}
+		scoreWeights[name] = n
+	}
+	return nil
+}
+
+// 0-100 confidence that mail to the address will be delivered, combining
+// the SMTP verdict, catch-all, address classification, domain
+// authentication and MX reputation
+func resultScore(res gin.H) int {
+	if _, failed := res["error"]; failed {
+		return 0
+	}
+	result, _ := res["reply_class"].(string)
+	if _, known := scoreWeights[result]; !known {
+		result = "unknown"
+	}
+	if result == "undeliverable" {
+		return clampScore(scoreWeights[result])
+	}
+
+	score := scoreWeights[result]
+	if res["catch_all"] == true && res["reason"] != "vrfy_confirmed" {
+		score += scoreWeights["catch_all"]
+	}
+	if disposable, _ := res["is_disposable"].(bool); disposable {
+		score += scoreWeights["disposable"]
+	}
+	if toxic, _ := res["is_toxic"].(bool); toxic {
+		score += scoreWeights["toxic"]
+	}
+	if role, _ := res["is_role_account"].(bool); role {
+		score += scoreWeights["role_account"]
+	}
+	if spf, _ := res["spf"].(gin.H); spf["exists"] == true {
+		score += scoreWeights["spf"]
+	}
+	if dmarc, _ := res["dmarc"].(gin.H); dmarc["exists"] == true {
+		score += scoreWeights["dmarc"]
+	}
+	listed, _ := res["blacklisted_on"].([]string)
+	score += len(listed) * scoreWeights["blacklisted"]
+	return clampScore(score)
+}
+
+func clampScore(n int) int {
+	return max(0, min(100, n))
+}
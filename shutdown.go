@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// How long SIGTERM/SIGINT waits for in-flight work before cutting it off
+// (DRAIN_TIMEOUT)
+var drainTimeout = 30 * time.Second
+
+// Set once shutdown begins; readyz then fails so load balancers move on
+var draining atomic.Bool
+
+// Base context of requests and async jobs, canceled when the drain timeout
+// runs out so whatever is left aborts instead of being cut mid-command
+var hardStop, stopHard = context.WithCancel(context.Background())
+
+// Async jobs and SMTP sessions under way
+var busy atomic.Int64
+
+// Serve app until SIGTERM or SIGINT, then shut down: stop accepting
+// connections, let in-flight requests, jobs and SMTP sessions finish within
+// drainTimeout, flush the history queue and traces, and return
+func serve(app *gin.Engine, grpcSrv *grpc.Server, flushTraces func(context.Context) error) {
+	srv := &http.Server{
+		Addr:        listenAddr,
+		Handler:     app,
+		BaseContext: func(net.Listener) context.Context { return hardStop },
+	}
+	failed := make(chan error, 1)
+	go func() { failed <- srv.ListenAndServe() }()
+	slog.Info("HTTP listening", "addr", listenAddr)
+
+	sig, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-failed:
+		fatal("HTTP server stopped", "error", err)
+	case <-sig.Done():
+	}
+	stopSignals() // a second signal kills the process the usual way
+
+	slog.Info("shutting down", "drain_timeout", drainTimeout.String())
+	draining.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := srv.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			slog.Error("HTTP shutdown failed", "error", err)
+		}
+	}()
+	if grpcSrv != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				grpcSrv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				grpcSrv.Stop()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Jobs and WebSocket checks aren't requests the servers wait for
+	tick := time.NewTicker(100 * time.Millisecond)
+	for busy.Load() > 0 && ctx.Err() == nil {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+		}
+	}
+	tick.Stop()
+	if n := busy.Load(); n > 0 {
+		slog.Warn("drain timeout reached, aborting remaining work", "jobs_and_sessions", n)
+	}
+	stopHard()
+
+	if history != nil {
+		history.close()
+	}
+	if closer, ok := verifyCache.(interface{ close() error }); ok {
+		closer.close()
+	}
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := flushTraces(flushCtx); err != nil {
+		slog.Warn("flushing traces failed", "error", err)
+	}
+	slog.Info("stopped")
+}
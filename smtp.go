@@ -0,0 +1,562 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Ask VRFY/EXPN about the recipient when the server advertises them (VRFY_CHECK=on)
+var vrfyCheckEnabled bool
+
+type smtpResult struct {
+	logs  map[string]string
+	err   error
+	email string
+
+	started    time.Time
+	duration   time.Duration
+	transcript []transcriptLine
+
+	connected     bool
+	banner        string
+	tls           string // not_offered, ok or failed
+	tlsError      string
+	peerCerts     []*x509.Certificate // chain presented during STARTTLS
+	vrfyCmd       string              // VRFY or EXPN, when one was sent
+	vrfy          []string            // its reply lines
+	mailFrom      []string            // MAIL FROM reply lines
+	rcpt          []string            // RCPT TO reply lines
+	timedOut      bool                // a reply didn't arrive before its deadline
+	attempts      int                 // conversations tried, counting retries
+	port          int                 // 25, or the submission port that answered when 25 didn't
+	proxy         string              // SOCKS5 proxy the connection went through, "" when direct
+	sourceIP      string              // local address of a direct connection, when SMTP_SOURCE_IPS picks one
+	addressFamily string              // "ipv4" or "ipv6" for direct connections
+	helo          string              // name sent in EHLO
+	sender        string              // address sent in MAIL FROM, "<>" for the null sender
+}
+
+// Deadlines of an SMTP session
+type smtpTimeouts struct {
+	dial    time.Duration // accepting the connection, before the next MX is tried
+	banner  time.Duration // the greeting
+	command time.Duration // each reply, from when its command is sent
+	session time.Duration // everything, across all recipients
+}
+
+// Timeouts of probes that don't set their own; SMTP_*_TIMEOUT overrides
+var defaultSMTPTimeouts = smtpTimeouts{
+	dial:    10 * time.Second,
+	banner:  30 * time.Second,
+	command: 30 * time.Second,
+	session: 2 * time.Minute,
+}
+
+// Port MX hosts are probed on; only tests point it elsewhere
+var mxPort = 25
+
+// Longest timeout a request may ask for
+const maxSMTPTimeout = 5 * time.Minute
+
+// Conn that arms a fresh per-command deadline on every write, never past
+// the end of the session
+type deadlineConn struct {
+	net.Conn
+	command time.Duration
+	end     time.Time
+}
+
+func newDeadlineConn(conn net.Conn, timeouts smtpTimeouts, start time.Time) *deadlineConn {
+	return &deadlineConn{Conn: conn, command: timeouts.command, end: start.Add(timeouts.session)}
+}
+
+// Set the read/write deadline d from now, capped at the session's end
+func (c *deadlineConn) arm(d time.Duration) {
+	deadline := time.Now().Add(d)
+	if deadline.After(c.end) {
+		deadline = c.end
+	}
+	c.Conn.SetDeadline(deadline)
+}
+
+func (c *deadlineConn) Write(p []byte) (int, error) {
+	c.arm(c.command)
+	return c.Conn.Write(p)
+}
+
+// Report whether err is a deadline expiring
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Wording greylisting servers (Postgrey, Exim, Postfix policy daemons, ...)
+// use in their temporary RCPT rejections
+var greylistRe = regexp.MustCompile(`(?i)gr[ae]y[- ]?list|try again later|retry later|come back later|temporarily (deferred|rejected)`)
+
+// Wording of over-quota rejections, for servers that send no enhanced code
+var mailboxFullRe = regexp.MustCompile(`(?i)over ?quota|quota (exceeded|full)|exceeded (its|the|their)? ?(storage|quota)|mailbox (is )?full|insufficient (system )?storage|out of storage`)
+
+// Wording of rejections for accounts that exist but were shut down: Gmail's
+// "account ... is disabled", Yahoo's "This mailbox is disabled", and the
+// suspended/inactive notices of hosting providers
+var mailboxDisabledRe = regexp.MustCompile(`(?i)\b(disabled|suspended|inactive|deactivated|no longer active|not active)\b`)
+
+var enhancedCodeRe = regexp.MustCompile(`^[245]\.\d{1,3}\.\d{1,3}$`)
+
+// RFC 3463 subject.detail pairs, whatever the class digit, and what they
+// mean for the recipient
+var enhancedReasons = map[string]string{
+	"1.1": "mailbox_not_found",
+	"1.2": "domain_not_found",
+	"1.3": "invalid_address",
+	"1.6": "mailbox_moved",
+	"2.1": "mailbox_disabled",
+	"2.2": "mailbox_full",
+	"2.3": "message_too_large",
+	"3.2": "system_not_accepting",
+	"4.4": "unable_to_route",
+	"4.7": "delivery_expired",
+	"7.1": "relay_denied",
+}
+
+// Reason for an enhanced status code such as "5.1.1", falling back to the
+// subject alone for details we don't map; "" when there is no code
+func enhancedReason(code string) string {
+	if code == "" {
+		return ""
+	}
+	_, rest, _ := strings.Cut(code, ".")
+	if r, ok := enhancedReasons[rest]; ok {
+		return r
+	}
+	subject, _, _ := strings.Cut(rest, ".")
+	switch subject {
+	case "1":
+		return "address_rejected"
+	case "2":
+		return "mailbox_unavailable"
+	case "3":
+		return "mail_system_error"
+	case "4":
+		return "network_error"
+	case "5":
+		return "protocol_error"
+	case "6":
+		return "content_rejected"
+	case "7":
+		return "policy_rejected"
+	}
+	return "other"
+}
+
+// Split a reply into its code, RFC 3463 enhanced code (if any) and text
+func parseReply(lines []string) (int, string, string) {
+	if len(lines) == 0 || len(lines[0]) < 3 {
+		return 0, "", ""
+	}
+	code, _ := strconv.Atoi(lines[0][:3])
+	enhanced := ""
+	var text []string
+	for _, line := range lines {
+		if len(line) < 4 {
+			continue
+		}
+		msg := line[4:]
+		if first, rest, _ := strings.Cut(msg, " "); enhancedCodeRe.MatchString(first) {
+			enhanced = first
+			msg = rest
+		}
+		text = append(text, msg)
+	}
+	return code, enhanced, strings.Join(text, " ")
+}
+
+// Server accepted the connection and sent a banner, then dropped us on EHLO.
+// Usually means the probing IP has poor reputation (e.g. a cloud range).
+var errBlockedAfterBanner = errors.New("blocked after banner — likely IP reputation")
+
+var errMailFromRejected = errors.New("MAIL FROM rejected")
+
+// A non-ASCII mailbox can only be named to a server offering SMTPUTF8
+// (RFC 6531)
+var errRequiresSMTPUTF8 = errors.New("server does not offer SMTPUTF8")
+
+// Detect a connection torn down by the peer
+func isConnDropped(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// Read a possibly multi-line SMTP reply
+func readReply(reader *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+		if len(line) < 4 || line[3] != '-' {
+			return lines, nil
+		}
+	}
+}
+
+// Send EHLO and return the reply lines
+func sendEHLO(conn net.Conn, reader *bufio.Reader, hostName string) ([]string, error) {
+	if _, err := fmt.Fprintf(conn, "EHLO %s\r\n", hostName); err != nil {
+		return nil, err
+	}
+	return readReply(reader)
+}
+
+// Report whether an EHLO reply advertises the given extension
+func hasCapability(ehlo []string, name string) bool {
+	for _, line := range ehlo {
+		if len(line) < 4 {
+			continue
+		}
+		if fields := strings.Fields(line[4:]); len(fields) > 0 && strings.EqualFold(fields[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue STARTTLS and perform the handshake. The returned conn is nil when
+// the server refused the command or the handshake failed.
+func startTLS(conn net.Conn, reader *bufio.Reader, serverName string) (*tls.Conn, string, error) {
+	if _, err := fmt.Fprintf(conn, "STARTTLS\r\n"); err != nil {
+		return nil, "", err
+	}
+	reply, err := readReply(reader)
+	resp := strings.Join(reply, "\n")
+	if err != nil || !strings.HasPrefix(resp, "220") {
+		return nil, resp, err
+	}
+	if tc, ok := conn.(*transcriptConn); ok {
+		conn = tc.Conn // the handshake itself isn't transcribed
+	}
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, resp, err
+	}
+	return tlsConn, resp, nil
+}
+
+// Perform basic SMTP check
+func smtpCheck(ctx context.Context, mxHost, rcptTo string) smtpResult {
+	return smtpSession(ctx, mxHost, defaultSMTPTimeouts, rcptTo)[0]
+}
+
+// Check several recipients over one SMTP session, resetting the envelope
+// with RSET between them: MAIL FROM, RCPT a, RSET, MAIL FROM, RCPT b, ...
+// Verifiers that open parallel connections per address are easy to spot.
+// Returns one result per recipient, sharing the session details. The
+// connection is closed as soon as ctx ends. The sender and EHLO name come
+// from pickIdentity.
+func smtpSession(ctx context.Context, mxHost string, timeouts smtpTimeouts, rcpts ...string) (out []smtpResult) {
+	ctx, span := tracer.Start(ctx, "smtp session", trace.WithAttributes(
+		attribute.String("smtp.mx_host", mxHost),
+		attribute.Int("smtp.recipients", len(rcpts)),
+	))
+	defer span.End()
+	logs := make(map[string]string)
+	id := pickIdentity(ctx)
+	hostName := id.helo
+	res := smtpResult{logs: logs, tls: "not_offered", started: time.Now(), helo: id.helo, sender: id.mailFrom}
+	tr := &transcript{start: res.started}
+	defer func() {
+		duration := time.Since(res.started)
+		for i := range out {
+			out[i].duration = duration
+			out[i].transcript = tr.lines
+		}
+		stats.recordSMTP(duration)
+		slog.DebugContext(ctx, "smtp session", "mx_host", mxHost, "helo", hostName,
+			"connected", res.connected, "tls", res.tls, "recipients", len(rcpts),
+			"duration_ms", duration.Milliseconds(), "error", res.err)
+	}()
+	// Every recipient shares a session that ended early
+	all := func() []smtpResult {
+		for _, rcptTo := range rcpts {
+			r := res
+			r.email = rcptTo
+			out = append(out, r)
+		}
+		return out
+	}
+
+	// A host that keeps failing isn't worth waiting out another timeout
+	if !circuitAllows(mxHost) {
+		tr.add("*", "skipping "+mxHost+": "+errCircuitOpen.Error())
+		logs["connection"] = errCircuitOpen.Error()
+		res.err = errCircuitOpen
+		return all()
+	}
+
+	// Wait our turn with the recipients' domain
+	stage := startSMTPStage(ctx, "throttle")
+	release, err := throttleDomain(ctx, emailDomain(rcpts[0]), len(rcpts))
+	stage.end(nil, err)
+	if err != nil {
+		res.err = err
+		return all()
+	}
+	defer release()
+
+	smtpSessionsInFlight.Inc()
+	defer smtpSessionsInFlight.Dec()
+	busy.Add(1)
+	defer busy.Add(-1)
+
+	addr := net.JoinHostPort(mxHost, strconv.Itoa(mxPort))
+	tr.add("*", "connecting to "+addr)
+	stage = startSMTPStage(ctx, "dial")
+	raw, via, err := dialSMTP(ctx, addr, emailDomain(rcpts[0]), min(timeouts.dial, timeouts.session))
+	stage.end(nil, err)
+	res.proxy = via
+	if err != nil {
+		if ctx.Err() == nil {
+			circuitFailure(mxHost)
+		}
+		tr.add("*", err.Error())
+		logs["connection"] = fmt.Sprintf("connection error: %v", err)
+		res.err = err
+		return all()
+	}
+	defer raw.Close()
+	stop := context.AfterFunc(ctx, func() { raw.Close() })
+	defer stop()
+	if via != "" {
+		tr.add("*", "connected to "+addr+" via proxy "+via)
+	} else {
+		tr.add("*", "connected to "+raw.RemoteAddr().String()+" from "+raw.LocalAddr().String())
+		res.addressFamily = addressFamily(raw.RemoteAddr())
+		if len(smtpSourceIPs) > 0 {
+			res.sourceIP = raw.LocalAddr().(*net.TCPAddr).IP.String()
+		}
+	}
+	dc := newDeadlineConn(raw, timeouts, res.started)
+	var conn net.Conn = &transcriptConn{Conn: dc, t: tr}
+	reader := bufio.NewReader(conn)
+	logs["connection"] = "connected"
+	res.connected = true
+	res.port = mxPort
+
+	// Read server banner
+	dc.arm(timeouts.banner)
+	stage = startSMTPStage(ctx, "banner")
+	banner, bannerErr := readReply(reader)
+	stage.end(banner, bannerErr)
+	logs["banner"] = strings.Join(banner, "\n")
+	res.banner = logs["banner"]
+	if bannerErr == nil {
+		circuitSuccess(mxHost)
+	} else if ctx.Err() == nil {
+		circuitFailure(mxHost)
+	}
+	if isTimeout(bannerErr) {
+		logs["banner"] = fmt.Sprintf("no banner within %v", timeouts.banner)
+		res.timedOut = true
+		return all()
+	}
+
+	// EHLO first
+	stage = startSMTPStage(ctx, "ehlo")
+	caps, ehloErr := sendEHLO(conn, reader, hostName)
+	stage.end(caps, ehloErr)
+	if bannerErr == nil && ehloErr != nil && isConnDropped(ehloErr) {
+		logs["ehlo"] = fmt.Sprintf("%v: %v", errBlockedAfterBanner, ehloErr)
+		res.err = errBlockedAfterBanner
+		return all()
+	}
+	if hasCapability(caps, "STARTTLS") {
+		logs["ehlo_caps"] = "STARTTLS supported"
+		stage = startSMTPStage(ctx, "starttls")
+		tlsConn, resp, err := startTLS(conn, reader, mxHost)
+		stage.end(strings.Split(resp, "\n"), err)
+		if tlsConn != nil {
+			state := tlsConn.ConnectionState()
+			tr.add("*", "TLS handshake ok: "+tls.VersionName(state.Version)+" "+tls.CipherSuiteName(state.CipherSuite))
+			conn = &transcriptConn{Conn: tlsConn, t: tr}
+			reader = bufio.NewReader(conn)
+			logs["tls"] = "TLS handshake successful"
+			res.tls = "ok"
+			res.peerCerts = state.PeerCertificates
+			stage = startSMTPStage(ctx, "ehlo") // EHLO after TLS
+			caps, err = sendEHLO(conn, reader, hostName)
+			stage.end(caps, err)
+		} else if err != nil {
+			tr.add("*", "TLS handshake failed: "+err.Error())
+			logs["tls"] = fmt.Sprintf("TLS handshake failed: %v", err)
+			res.tls = "failed"
+			res.tlsError = err.Error()
+		}
+	}
+
+	for i, rcptTo := range rcpts {
+		r := res
+		r.email = rcptTo
+		r.logs = make(map[string]string, len(logs)+4)
+		for k, v := range logs {
+			r.logs[k] = v
+		}
+		out = append(out, smtpEnvelope(ctx, conn, reader, r, id.mailFrom, caps, i > 0))
+	}
+
+	fmt.Fprintf(conn, "QUIT\r\n")
+
+	return out
+}
+
+// Run one recipient's part of a session: RSET when the envelope was used
+// before, VRFY/EXPN if enabled, MAIL FROM and RCPT TO
+func smtpEnvelope(ctx context.Context, conn net.Conn, reader *bufio.Reader, res smtpResult, mailFrom string, caps []string, reset bool) smtpResult {
+	logs := res.logs
+	rcptTo := res.email
+
+	if reset {
+		stage := startSMTPStage(ctx, "rset")
+		fmt.Fprintf(conn, "RSET\r\n")
+		reply, err := readReply(reader)
+		stage.end(reply, err)
+		if resp := strings.Join(reply, "\n"); !strings.HasPrefix(resp, "250") {
+			logs["rset"] = fmt.Sprintf("RSET rejected: %s", resp)
+			res.err = errors.New("RSET rejected")
+			return res
+		}
+	}
+
+	// An internationalized local part goes out as UTF-8 with the SMTPUTF8
+	// parameter, or not at all
+	param := ""
+	if !isASCII(rcptTo) {
+		if !hasCapability(caps, "SMTPUTF8") {
+			logs["rcpt_to"] = "not sent: the mailbox needs SMTPUTF8, which the server doesn't offer"
+			res.err = errRequiresSMTPUTF8
+			return res
+		}
+		param = " SMTPUTF8"
+	}
+
+	if vrfyCheckEnabled {
+		switch {
+		case hasCapability(caps, "VRFY"):
+			res.vrfyCmd = "VRFY"
+		case hasCapability(caps, "EXPN"):
+			res.vrfyCmd = "EXPN"
+		}
+		if res.vrfyCmd != "" {
+			stage := startSMTPStage(ctx, "vrfy")
+			fmt.Fprintf(conn, "%s %s%s\r\n", res.vrfyCmd, rcptTo, param)
+			var err error
+			res.vrfy, err = readReply(reader)
+			stage.end(res.vrfy, err)
+			logs["vrfy"] = strings.Join(res.vrfy, "\n")
+		}
+	}
+
+	// MAIL FROM
+	stage := startSMTPStage(ctx, "mail_from")
+	fmt.Fprintf(conn, "MAIL FROM:<%s>%s\r\n", strings.Trim(mailFrom, "<>"), param)
+	var err error
+	res.mailFrom, err = readReply(reader)
+	stage.end(res.mailFrom, err)
+	mailResp := strings.Join(res.mailFrom, "\n")
+	if isTimeout(err) {
+		logs["mail_from"] = "MAIL FROM timed out"
+		res.timedOut = true
+		return res
+	}
+	if isConnDropped(err) {
+		logs["mail_from"] = fmt.Sprintf("connection dropped: %v", err)
+		res.err = err
+		return res
+	}
+	if !strings.HasPrefix(mailResp, "250") {
+		logs["mail_from"] = fmt.Sprintf("MAIL FROM rejected: %s", mailResp)
+		res.err = errMailFromRejected
+		return res
+	}
+	logs["mail_from"] = "MAIL FROM accepted"
+
+	// RCPT TO
+	stage = startSMTPStage(ctx, "rcpt")
+	fmt.Fprintf(conn, "RCPT TO:<%s>\r\n", rcptTo)
+	res.rcpt, err = readReply(reader)
+	stage.end(res.rcpt, err)
+	logs["rcpt_to"] = strings.Join(res.rcpt, "\n")
+	res.timedOut = isTimeout(err)
+	if isConnDropped(err) {
+		res.err = err
+	}
+	return res
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// Report whether a 450/451 RCPT reply looks like greylisting rather than
+// some other temporary failure
+func isGreylisted(rcpt []string) bool {
+	code, _, msg := parseReply(rcpt)
+	return (code == 450 || code == 451) && greylistRe.MatchString(msg)
+}
+
+// A 452 or 552 RCPT reply saying the mailbox is over its quota. The mailbox
+// exists and usually accepts mail again once emptied.
+func isMailboxFull(rcpt []string) bool {
+	code, enhanced, msg := parseReply(rcpt)
+	if code != 452 && code != 552 {
+		return false
+	}
+	return enhancedReason(enhanced) == "mailbox_full" || mailboxFullRe.MatchString(msg)
+}
+
+// A permanent RCPT rejection because the account was disabled or suspended.
+// Unlike a typo the address did exist, but it won't take mail again.
+func isMailboxDisabled(rcpt []string) bool {
+	code, enhanced, msg := parseReply(rcpt)
+	if code < 500 {
+		return false
+	}
+	return enhancedReason(enhanced) == "mailbox_disabled" || mailboxDisabledRe.MatchString(msg)
+}
+
+func smtpStatus(code int) string {
+	switch code {
+	case 250:
+		return "Deliverable"
+	case 550:
+		return "Mailbox unavailable / not found / relay denied"
+	default:
+		return "Other SMTP response"
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Start a fake MX on a local port, pointing mxPort at it for the test. Every
+// connection is handed to serve.
+func fakeMX(t *testing.T, serve func(conn net.Conn, r *bufio.Reader)) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn, bufio.NewReader(conn))
+			}()
+		}
+	}()
+	oldPort, oldHelo := mxPort, heloNames
+	mxPort = ln.Addr().(*net.TCPAddr).Port
+	heloNames = []string{"probe.example.com"}
+	t.Cleanup(func() {
+		mxPort, heloNames = oldPort, oldHelo
+		mxCircuits.Lock()
+		clear(mxCircuits.hosts)
+		mxCircuits.Unlock()
+	})
+}
+
+// Answer each command with the reply replies gives it, "" to hang up
+func scriptedMX(replies func(cmd string) string) func(net.Conn, *bufio.Reader) {
+	return func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("220 mx.example.com ESMTP\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			reply := replies(strings.TrimRight(line, "\r\n"))
+			if reply == "" {
+				return
+			}
+			conn.Write([]byte(reply))
+		}
+	}
+}
+
+func testTimeouts() smtpTimeouts {
+	return smtpTimeouts{dial: 2 * time.Second, banner: 2 * time.Second, command: 2 * time.Second, session: 5 * time.Second}
+}
+
+func TestBlockedAfterBanner(t *testing.T) {
+	for _, reset := range []bool{false, true} {
+		fakeMX(t, func(conn net.Conn, r *bufio.Reader) {
+			conn.Write([]byte("220 mx.example.com ESMTP\r\n"))
+			r.ReadString('\n') // EHLO
+			if reset {
+				conn.(*net.TCPConn).SetLinger(0) // RST instead of FIN
+			}
+		})
+		res := smtpSession(context.Background(), "127.0.0.1", testTimeouts(), "someone@example.com")[0]
+		if !res.connected || res.banner == "" {
+			t.Fatalf("reset=%v: banner not read: %+v", reset, res)
+		}
+		if got := probeReason(res, false); got != "blocked_after_banner" {
+			t.Errorf("reset=%v: reason = %q, want blocked_after_banner", reset, got)
+		}
+	}
+}
+
+func TestRejectedRecipient(t *testing.T) {
+	fakeMX(t, scriptedMX(func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			return "250 mx.example.com\r\n"
+		case strings.HasPrefix(cmd, "RCPT"):
+			return "550 5.1.1 no such user\r\n"
+		case cmd == "QUIT":
+			return ""
+		}
+		return "250 ok\r\n"
+	}))
+	res := smtpSession(context.Background(), "127.0.0.1", testTimeouts(), "nobody@example.com")[0]
+	if got := probeReason(res, false); got != "rejected" {
+		t.Errorf("reason = %q, want rejected", got)
+	}
+}
+
+// Answer MX queries for domain with host until the test ends
+func fakeMXRecord(t *testing.T, domain, host string) {
+	t.Helper()
+	e := &dnsCacheEntry{done: make(chan struct{}), expires: time.Now().Add(time.Hour)}
+	e.msg = &dnsmessage.Message{Answers: []dnsmessage.Resource{{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(domain + "."), Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET, TTL: 3600},
+		Body:   &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName(host + ".")},
+	}}}
+	close(e.done)
+	key := dnsmessage.TypeMX.String() + " " + domain
+	dnsCache.Lock()
+	dnsCache.entries[key] = e
+	dnsCache.Unlock()
+	t.Cleanup(func() {
+		dnsCache.Lock()
+		delete(dnsCache.entries, key)
+		dnsCache.Unlock()
+	})
+}
+
+// Script of an MX accepting every recipient
+func acceptingMX(cmd string) string {
+	switch {
+	case strings.HasPrefix(cmd, "EHLO"):
+		return "250-mx.example.com\r\n250 PIPELINING\r\n"
+	case cmd == "QUIT":
+		return ""
+	}
+	return "250 ok\r\n"
+}
+
+// A blocked outbound port 25 opens every circuit; the submission fallback
+// must still run and tell the block apart from a dead host
+func TestPort25BlockedWithOpenCircuit(t *testing.T) {
+	fakeMX(t, scriptedMX(acceptingMX))
+	submissionPort := mxPort
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mxPort = closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+	oldPorts := submissionPorts
+	submissionPorts = []int{submissionPort}
+	t.Cleanup(func() { submissionPorts = oldPorts })
+
+	for range mxCircuitFailures {
+		circuitFailure("127.0.0.1")
+	}
+	_, results := smtpCheckHosts(context.Background(), []string{"127.0.0.1"}, testTimeouts(), "someone@example.com")
+	if got := probeReason(results[0], false); got != "port_25_blocked" {
+		t.Errorf("reason = %q, want port_25_blocked", got)
+	}
+	if results[0].port != submissionPort {
+		t.Errorf("port = %d, want %d", results[0].port, submissionPort)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permanently rejected addresses remembered to spot them coming back
+const spamtrapMaxRemembered = 100000
+
+// When each address was last permanently rejected
+var rejectedAddresses = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// Record a permanent rejection of email, or report whether an address
+// accepted now was rejected before. Dead mailboxes that come back to life
+// are the classic recycled trap.
+func revivedAddress(email string, body gin.H) bool {
+	rejectedAddresses.Lock()
+	defer rejectedAddresses.Unlock()
+	switch {
+	case body["reason"] == "rejected", body["reason"] == "mailbox_disabled":
+		if _, ok := rejectedAddresses.at[email]; ok || len(rejectedAddresses.at) < spamtrapMaxRemembered {
+			rejectedAddresses.at[email] = time.Now()
+		}
+	case body["reply_class"] == "deliverable":
+		_, ok := rejectedAddresses.at[email]
+		return ok
+	}
+	return false
+}
+
+// Rough odds that email is a spam trap: high for known trap domains and
+// revived addresses, otherwise one step up for each sign of a dormant
+// domain someone took over, an accept-all domain without SPF or DMARC and
+// a role address on one
+func spamtrapRisk(email string, body gin.H) string {
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if classifier.IsSpamtrapDomain(domain) || revivedAddress(email, body) {
+		return "high"
+	}
+	spf, _ := body["spf"].(gin.H)
+	dmarc, _ := body["dmarc"].(gin.H)
+	if spf["exists"] == true || dmarc["exists"] == true {
+		return "low"
+	}
+	signs := 0
+	if body["catch_all"] == true {
+		signs++
+	}
+	if body["is_role_account"] == true {
+		signs++
+	}
+	return []string{"low", "medium", "high"}[signs]
+}
+
+// Add spamtrap_risk to a deep result body; a deliverable address with a
+// high risk is marked risky
+func applySpamtrapRisk(email string, body gin.H) {
+	risk := spamtrapRisk(email, body)
+	body["spamtrap_risk"] = risk
+	if ok, _ := body["isDeliverable"].(bool); ok && risk == "high" {
+		body["risky"] = true
+	}
+}
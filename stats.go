@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/subtle"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Hours of per-hour verification counts kept for /admin/stats
+const statsHours = 24
+
+// Distinct domains tracked for the top-domains list
+const statsMaxDomains = 10000
+
+// In-process service counters
+type serviceStats struct {
+	mu          sync.Mutex
+	started     time.Time
+	hourly      map[int64]int // unix hour -> verifications
+	outcomes    map[string]int
+	domains     map[string]int
+	cacheHits   int
+	cacheMisses int
+	smtpCount   int
+	smtpTotal   time.Duration
+}
+
+var stats = &serviceStats{
+	started:  time.Now(),
+	hourly:   make(map[int64]int),
+	outcomes: make(map[string]int),
+	domains:  make(map[string]int),
+}
+
+func (s *serviceStats) recordVerification(email string, res gin.H) {
+	domain := email[strings.LastIndex(email, "@")+1:]
+	hour := time.Now().Unix() / 3600
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hourly[hour]++
+	for h := range s.hourly {
+		if h <= hour-statsHours {
+			delete(s.hourly, h)
+		}
+	}
+	result, _ := res["result"].(string)
+	s.outcomes[result]++
+	if _, ok := s.domains[domain]; ok || len(s.domains) < statsMaxDomains {
+		s.domains[domain]++
+	}
+}
+
+func (s *serviceStats) recordCache(hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+}
+
+func (s *serviceStats) recordSMTP(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.smtpCount++
+	s.smtpTotal += d
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+func (s *serviceStats) snapshot() gin.H {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hour := time.Now().Unix() / 3600
+	perHour := make([]gin.H, 0, statsHours)
+	for h := hour - statsHours + 1; h <= hour; h++ {
+		perHour = append(perHour, gin.H{"hour": time.Unix(h*3600, 0).UTC(), "count": s.hourly[h]})
+	}
+
+	total := 0
+	for _, n := range s.outcomes {
+		total += n
+	}
+	outcomes := gin.H{}
+	for _, k := range canonicalResults {
+		outcomes[k] = gin.H{"count": s.outcomes[k], "ratio": ratio(s.outcomes[k], total)}
+	}
+
+	type domainCount struct {
+		Domain string `json:"domain"`
+		Count  int    `json:"count"`
+	}
+	top := make([]domainCount, 0, len(s.domains))
+	for d, n := range s.domains {
+		top = append(top, domainCount{d, n})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Domain < top[j].Domain
+	})
+	if len(top) > 10 {
+		top = top[:10]
+	}
+
+	avgMs := 0.0
+	if s.smtpCount > 0 {
+		avgMs = float64(s.smtpTotal.Milliseconds()) / float64(s.smtpCount)
+	}
+
+	return gin.H{
+		"uptime_seconds":         int(time.Since(s.started).Seconds()),
+		"verifications_total":    total,
+		"verifications_per_hour": perHour,
+		"outcomes":               outcomes,
+		"top_domains":            top,
+		"cache": gin.H{
+			"enabled":  verifyCache != nil,
+			"hits":     s.cacheHits,
+			"misses":   s.cacheMisses,
+			"hit_rate": ratio(s.cacheHits, s.cacheHits+s.cacheMisses),
+		},
+		"smtp":     gin.H{"sessions": s.smtpCount, "avg_latency_ms": avgMs},
+		"api_keys": quotas.snapshot(),
+	}
+}
+
+// Bearer token of the admin API (ADMIN_TOKEN)
+var adminToken string
+
+// Guard admin routes with the ADMIN_TOKEN bearer token. Without a token
+// configured the admin API is disabled.
+func requireAdmin() gin.HandlerFunc {
+	token := adminToken
+	want := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(404, gin.H{"error": apiError("admin_disabled", "Admin API disabled")})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), want) != 1 {
+			c.AbortWithStatusJSON(401, gin.H{"error": apiError("unauthorized", "Unauthorized")})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GET /admin/stats
+func adminStatsHandler(c *gin.Context) {
+	c.JSON(200, stats.snapshot())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stats count the canonical result, so a DNS failure or a deadline isn't
+// reported as undeliverable
+func TestStatsCountCanonicalResult(t *testing.T) {
+	s := &serviceStats{started: time.Now(), hourly: map[int64]int{}, outcomes: map[string]int{}, domains: map[string]int{}}
+	for _, res := range []gin.H{
+		{"error": apiError("dns_failure", "DNS lookup failed")},
+		{"error": apiError("deadline_exceeded", "Request deadline exceeded")},
+		{"reply_class": "undeliverable", "reason": "rejected"},
+	} {
+		res["result"] = canonicalResult(res)
+		s.recordVerification("someone@example.com", res)
+	}
+	outcomes := s.snapshot()["outcomes"].(gin.H)
+	for result, want := range map[string]int{"dns_error": 1, "unknown": 1, "undeliverable": 1} {
+		if got := outcomes[result].(gin.H)["count"]; got != want {
+			t.Errorf("%s: count = %v, want %d", result, got, want)
+		}
+	}
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	old := adminToken
+	adminToken = "s3cret"
+	t.Cleanup(func() { adminToken = old })
+	guard := requireAdmin()
+	for header, want := range map[string]int{
+		"Bearer s3cret":  200,
+		"Bearer s3cre":   401,
+		"Bearer s3cretx": 401,
+		"":               401,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/admin/stats", nil)
+		c.Request.Header.Set("Authorization", header)
+		guard(c)
+		if c.IsAborted() != (want != 200) || c.IsAborted() && w.Code != want {
+			t.Errorf("%q: aborted = %v, status %d, want %d", header, c.IsAborted(), w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How often a progress event is sent while a job is streaming
+const streamProgressInterval = 2 * time.Second
+
+// GET /jobs/:id/stream
+//
+// Server-Sent Events: a "result" event per finished email (including ones
+// completed before the client connected), "progress" events on a timer and
+// a final "done" event.
+func jobStreamHandler(c *gin.Context) {
+	j, ok := requestedJob(c)
+	if !ok {
+		c.JSON(404, gin.H{"error": apiError("job_not_found", "Job not found")})
+		return
+	}
+
+	ticker := time.NewTicker(streamProgressInterval)
+	defer ticker.Stop()
+	sent := 0
+
+	c.Stream(func(w io.Writer) bool {
+		j.mu.Lock()
+		var pending []gin.H
+		for _, i := range j.order[sent:] {
+			res := copyH(j.results[i])
+			res["index"] = i
+			pending = append(pending, res)
+		}
+		sent = len(j.order)
+		done := j.status == "done"
+		summary := j.summary()
+		changed := j.changed
+		j.mu.Unlock()
+
+		for _, res := range pending {
+			c.SSEvent("result", res)
+		}
+		if done {
+			c.SSEvent("done", summary)
+			return false
+		}
+		if len(pending) > 0 {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			c.SSEvent("progress", summary)
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ports tried when port 25 can't be reached: 587 with STARTTLS and 465
+// with implicit TLS (RFC 8314)
+var submissionPorts = []int{587, 465}
+
+// Reach mxHost on a submission port after port 25 failed, which tells an
+// outbound port 25 block apart from a dead server. Submission wants
+// authentication before RCPT TO, so only the banner and TLS are looked at.
+// Fills in res and returns whether a port answered.
+func probeSubmission(ctx context.Context, mxHost string, timeouts smtpTimeouts, res *smtpResult) bool {
+	for _, port := range submissionPorts {
+		if ctx.Err() != nil {
+			return false
+		}
+		addr := net.JoinHostPort(mxHost, strconv.Itoa(port))
+		raw, _, err := dialSMTP(ctx, addr, emailDomain(res.email), min(timeouts.dial, timeouts.session))
+		if err != nil {
+			res.logs["submission_"+strconv.Itoa(port)] = fmt.Sprintf("connection error: %v", err)
+			continue
+		}
+		ok := submissionBanner(raw, mxHost, port, timeouts, res)
+		raw.Close()
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Read the greeting on a submission connection, after the TLS handshake on
+// 465 or followed by STARTTLS on 587
+func submissionBanner(raw net.Conn, mxHost string, port int, timeouts smtpTimeouts, res *smtpResult) bool {
+	key := "submission_" + strconv.Itoa(port)
+	dc := newDeadlineConn(raw, timeouts, time.Now())
+	var conn net.Conn = dc
+	dc.arm(timeouts.banner)
+	if port == 465 {
+		tlsConn := tls.Client(dc, &tls.Config{ServerName: mxHost, InsecureSkipVerify: true})
+		if err := tlsConn.Handshake(); err != nil {
+			res.logs[key] = fmt.Sprintf("TLS handshake failed: %v", err)
+			return false
+		}
+		conn = tlsConn
+		res.tls = "ok"
+	}
+	reader := bufio.NewReader(conn)
+	banner, err := readReply(reader)
+	if err != nil || !strings.HasPrefix(strings.Join(banner, "\n"), "220") {
+		res.logs[key] = fmt.Sprintf("no banner: %v", err)
+		return false
+	}
+	res.port = port
+	res.banner = strings.Join(banner, "\n")
+	res.logs[key] = "connected"
+
+	if port == 587 {
+		caps, _ := sendEHLO(conn, reader, res.helo)
+		if hasCapability(caps, "STARTTLS") {
+			tlsConn, _, err := startTLS(conn, reader, mxHost)
+			switch {
+			case tlsConn != nil:
+				conn = tlsConn
+				res.tls = "ok"
+			case err != nil:
+				res.tls = "failed"
+				res.tlsError = err.Error()
+				return true // the session is unusable after a failed handshake
+			}
+		}
+	}
+	fmt.Fprintf(conn, "QUIT\r\n")
+	return true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Well-known provider domains that typo suggestions point at
+var suggestDomains = mustLoadLines("providers.txt")
+
+func mustLoadLines(name string) []string {
+	lines, err := loadLines("", name)
+	if err != nil {
+		panic(err)
+	}
+	return lines
+}
+
+// Optimal string alignment distance: Levenshtein plus adjacent
+// transpositions, so "gmial" is one edit away from "gmail"
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// Closest provider domain to a likely misspelt one, "" when nothing is
+// close enough or the domain is itself a known provider
+func suggestDomain(domain string) string {
+	best, bestDist := "", 0
+	for _, candidate := range suggestDomains {
+		if candidate == domain {
+			return ""
+		}
+		// Allow one edit for short domains, two for longer ones
+		limit := 1
+		if len(candidate) >= 9 {
+			limit = 2
+		}
+		if dist := editDistance(domain, candidate); dist <= limit && (best == "" || dist < bestDist) {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// Set "suggestion" on a result when the domain looks like a typo
+func addSuggestion(email string, res gin.H) gin.H {
+	at := strings.LastIndex(email, "@")
+	if s := suggestDomain(email[at+1:]); s != "" {
+		res["suggestion"] = email[:at+1] + s
+	}
+	return res
+}
+
+// Response to a check whose MX lookup failed; a domain that doesn't exist
+// or has no MX may be a typo
+func mxErrorResult(email, domain string, err error) (int, gin.H) {
+	status, e := mxLookupFailure(domain, err)
+	res := gin.H{"error": e}
+	if e["code"] != "dns_failure" {
+		addSuggestion(email, res)
+	}
+	return status, res
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// RFC 5321 length limits
+const (
+	maxAddressLen   = 254
+	maxLocalPartLen = 64
+	maxDomainLen    = 253
+	maxLabelLen     = 63
+)
+
+// Outcome of parsing an address without touching the network
+type syntaxResult struct {
+	Email     string `json:"email"`
+	Valid     bool   `json:"valid"`
+	LocalPart string `json:"local_part,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// RFC 5322 atext, plus UTF-8 per RFC 6531
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r >= 0x80:
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+}
+
+// Validate a dot-atom or quoted-string local part
+func checkLocalPartSyntax(local string) string {
+	if local == "" {
+		return "empty_local_part"
+	}
+	if len(local) > maxLocalPartLen {
+		return "local_part_too_long"
+	}
+
+	if strings.HasPrefix(local, `"`) {
+		if len(local) < 2 || !strings.HasSuffix(local, `"`) {
+			return "invalid_local_part"
+		}
+		inner := local[1 : len(local)-1]
+		for i := 0; i < len(inner); i++ {
+			switch ch := inner[i]; {
+			case ch == '\\':
+				i++ // quoted-pair
+				if i == len(inner) {
+					return "invalid_local_part"
+				}
+			case ch == '"', ch < 32 && ch != '\t', ch == 127:
+				return "invalid_local_part"
+			}
+		}
+		return ""
+	}
+
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") {
+		return "leading_or_trailing_dot"
+	}
+	if strings.Contains(local, "..") {
+		return "consecutive_dots"
+	}
+	for _, r := range local {
+		if r != '.' && !isAtext(r) {
+			return "invalid_local_part"
+		}
+	}
+	return ""
+}
+
+// Validate a hostname or address literal, including a sane TLD
+func checkDomainSyntax(domain string) string {
+	if domain == "" {
+		return "empty_domain"
+	}
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		literal := domain[1 : len(domain)-1]
+		if v6, ok := strings.CutPrefix(strings.ToLower(literal), "ipv6:"); ok {
+			literal = v6
+		}
+		if net.ParseIP(literal) == nil {
+			return "invalid_address_literal"
+		}
+		return ""
+	}
+	if len(domain) > maxDomainLen {
+		return "domain_too_long"
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "missing_tld"
+	}
+	for _, label := range labels {
+		if label == "" {
+			return "consecutive_dots"
+		}
+		if len(label) > maxLabelLen {
+			return "label_too_long"
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "invalid_domain"
+		}
+		for _, r := range label {
+			ldh := r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+			if !ldh && r < 0x80 {
+				return "invalid_domain"
+			}
+		}
+	}
+
+	if _, err := idna.Lookup.ToASCII(domain); err != nil {
+		return "invalid_idn" // e.g. disallowed code points or bad punycode
+	}
+
+	// TLDs are alphabetic (or punycode), never numeric, at least 2 long
+	tld := strings.ToLower(labels[len(labels)-1])
+	if strings.HasPrefix(tld, "xn--") {
+		return ""
+	}
+	if len([]rune(tld)) < 2 {
+		return "invalid_tld"
+	}
+	for _, r := range tld {
+		if r >= '0' && r <= '9' || r == '-' {
+			return "invalid_tld"
+		}
+	}
+	return ""
+}
+
+// Report whether the TLD of domain is delegated in the root zone, going by
+// the ICANN section of the Public Suffix List embedded in x/net. Reserved
+// names such as .test, .example, .invalid and .local are not.
+func delegatedTLD(domain string) bool {
+	tld := asciiDomain(strings.ToLower(domain[strings.LastIndex(domain, ".")+1:]))
+	_, icann := publicsuffix.PublicSuffix(tld)
+	return icann
+}
+
+// Parse an address per RFC 5321/5322 without DNS or SMTP
+func validateSyntax(addr string) syntaxResult {
+	addr = strings.TrimSpace(addr)
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return syntaxResult{Email: addr, Reason: "missing_at"}
+	}
+	res := syntaxResult{Email: addr, LocalPart: addr[:at], Domain: strings.TrimSuffix(addr[at+1:], ".")}
+	if len(addr) > maxAddressLen {
+		res.Reason = "address_too_long"
+		return res
+	}
+	if res.Reason = checkLocalPartSyntax(res.LocalPart); res.Reason != "" {
+		return res
+	}
+	if res.Reason = checkDomainSyntax(res.Domain); res.Reason != "" {
+		return res
+	}
+	if !strings.HasPrefix(res.Domain, "[") && !delegatedTLD(res.Domain) {
+		res.Reason = "unknown_tld"
+		return res
+	}
+	res.Valid = true
+	return res
+}
+
+// Result body for an address that fails syntax validation, nil when it
+// parses; such addresses never reach DNS or SMTP
+func invalidSyntaxResult(email string) gin.H {
+	syn := validateSyntax(email)
+	if syn.Valid {
+		return nil
+	}
+	return gin.H{
+		"status":        "Invalid syntax",
+		"reason":        "invalid_syntax",
+		"reply_class":   "undeliverable",
+		"syntax_error":  syn.Reason,
+		"isDeliverable": false,
+		"risky":         false,
+	}
+}
+
+// Result body for an address whose local part matches an operator's
+// low-quality rule, nil when none matches; such addresses aren't probed
+func lowQualityResult(email string) gin.H {
+	at := strings.LastIndex(email, "@")
+	rule := classifier.LowQualityRule(email[:at])
+	if rule == "" {
+		return nil
+	}
+	body := gin.H{
+		"status":           "Low-quality local part",
+		"reason":           "low_quality",
+		"reply_class":      "unknown",
+		"low_quality_rule": rule,
+		"catch_all":        nil,
+		"isDeliverable":    false,
+		"risky":            true,
+	}
+	classifyAddress(body, email, "")
+	return body
+}
+
+// POST /validate-syntax {"email": "..."}
+func validateSyntaxHandler(c *gin.Context) {
+	req, ok := requestEmail(c)
+	if !ok {
+		c.JSON(400, gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+		return
+	}
+	respond(c, 200, validateSyntax(req.email))
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestValidateSyntaxTLD(t *testing.T) {
+	for _, tc := range []struct {
+		addr, reason string
+	}{
+		{"jane@example.com", ""},
+		{"jane@example.co.uk", ""},
+		{"jane@пример.рф", ""},
+		{"jane@[192.0.2.1]", ""},
+		{"jane@example.c0m", "invalid_tld"},
+		{"jane@example.test", "unknown_tld"},
+		{"jane@printer.local", "unknown_tld"},
+		{"jane@example.notatld", "unknown_tld"},
+	} {
+		if got := validateSyntax(tc.addr).Reason; got != tc.reason {
+			t.Errorf("%s: reason %q, want %q", tc.addr, got, tc.reason)
+		}
+	}
+}
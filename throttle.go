@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Per-recipient-domain caps shared by every request, job and stream: SMTP
+// connections open at once (DOMAIN_MAX_CONNECTIONS) and recipients probed
+// per minute (DOMAIN_PROBES_PER_MINUTE). "*" covers domains without an entry
+// of their own; no entry means unlimited.
+var (
+	domainMaxConnections   = map[string]int{"*": 3}
+	domainProbesPerMinute  = make(map[string]int)
+	domainProbeRateLimiter = newRateLimiter(0, time.Minute)
+)
+
+// Connections open per domain; waiters are woken through changed whenever
+// one closes
+var domainConns = struct {
+	sync.Mutex
+	open    map[string]int
+	changed chan struct{}
+}{open: make(map[string]int), changed: make(chan struct{})}
+
+// Wait until domain is under both caps, then take a connection and charge
+// probes recipients to it. The returned function gives the connection back.
+func throttleDomain(ctx context.Context, domain string, probes int) (func(), error) {
+	if err := waitProbeBudget(ctx, domain, probes); err != nil {
+		return nil, err
+	}
+	limit := keyLimit(domainMaxConnections, domain)
+	if limit == 0 {
+		return func() {}, nil
+	}
+	for {
+		domainConns.Lock()
+		if domainConns.open[domain] < limit {
+			domainConns.open[domain]++
+			domainConns.Unlock()
+			return func() { releaseDomain(domain) }, nil
+		}
+		changed := domainConns.changed
+		domainConns.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func releaseDomain(domain string) {
+	domainConns.Lock()
+	defer domainConns.Unlock()
+	if domainConns.open[domain]--; domainConns.open[domain] <= 0 {
+		delete(domainConns.open, domain)
+	}
+	close(domainConns.changed)
+	domainConns.changed = make(chan struct{})
+}
+
+// Charge probes recipients to domain's per-minute budget, waiting for the
+// next window while it is spent
+func waitProbeBudget(ctx context.Context, domain string, probes int) error {
+	limit := keyLimit(domainProbesPerMinute, domain)
+	if limit == 0 {
+		return nil
+	}
+	for charged := 0; charged < probes; {
+		_, reset, ok := domainProbeRateLimiter.take(domain, limit)
+		if ok {
+			charged++
+			continue
+		}
+		select {
+		case <-time.After(reset):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
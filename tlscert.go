@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Evaluate the certificate chain an MX presented during STARTTLS. The
+// handshake itself skips verification so the probe can go on; this is where
+// broken mail TLS gets reported. nil when no chain was presented.
+func evaluateCert(host string, chain []*x509.Certificate) gin.H {
+	if len(chain) == 0 {
+		return nil
+	}
+	leaf := chain[0]
+	now := time.Now()
+	inter := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		inter.AddCert(c)
+	}
+
+	// Chain trust and hostname are judged separately so each can be reported
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{Intermediates: inter, CurrentTime: now})
+	hostErr := leaf.VerifyHostname(host)
+	expired := now.After(leaf.NotAfter)
+	selfSigned := bytes.Equal(leaf.RawIssuer, leaf.RawSubject) &&
+		leaf.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature) == nil
+	out := gin.H{
+		"subject":           leaf.Subject.CommonName,
+		"issuer":            leaf.Issuer.CommonName,
+		"dns_names":         leaf.DNSNames,
+		"not_before":        leaf.NotBefore.UTC(),
+		"not_after":         leaf.NotAfter.UTC(),
+		"days_until_expiry": int(leaf.NotAfter.Sub(now).Hours() / 24),
+		"expired":           expired,
+		"not_yet_valid":     now.Before(leaf.NotBefore),
+		"self_signed":       selfSigned,
+		"hostname_match":    hostErr == nil,
+		"trusted":           verifyErr == nil,
+		"valid":             verifyErr == nil && hostErr == nil,
+	}
+	if len(leaf.Issuer.Organization) > 0 {
+		out["issuer_org"] = leaf.Issuer.Organization[0]
+	}
+	switch {
+	case verifyErr != nil:
+		out["error"] = verifyErr.Error()
+	case hostErr != nil:
+		out["error"] = hostErr.Error()
+	}
+	return out
+}
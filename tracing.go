@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTLP/HTTP collector traces are exported to (OTEL_EXPORTER_OTLP_ENDPOINT),
+// e.g. "http://localhost:4318"; empty leaves tracing off
+var otlpEndpoint string
+
+// Share of new traces recorded (TRACE_SAMPLE_RATIO); requests arriving with
+// a sampled traceparent are always recorded
+var traceSampleRatio = 1.0
+
+// Spans go nowhere until setupTracing installs an exporter
+var tracer = otel.Tracer("emailhunting")
+
+// Install the OTLP exporter, returning the function that flushes it
+func setupTracing() (func(context.Context) error, error) {
+	if otlpEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(otlpEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "emailhunting"))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(traceSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Server span around each request, continuing the caller's trace when a
+// traceparent header is sent; handlers pass it on through the request context
+func traceRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if name := apiKeyName(c); name != "" {
+			span.SetAttributes(attribute.String("api_key", name))
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// Mark span failed with err, if any, and end it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// One step of an SMTP conversation, timed for the stage histogram and
+// traced as a span of the session
+type smtpStage struct {
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+func startSMTPStage(ctx context.Context, name string) smtpStage {
+	_, span := tracer.Start(ctx, "smtp "+name)
+	return smtpStage{name: name, start: time.Now(), span: span}
+}
+
+// End the stage with the server's reply to it. Rejections are ordinary
+// outcomes, so only transport errors mark the span failed.
+func (s smtpStage) end(reply []string, err error) {
+	smtpStageSeconds.WithLabelValues(s.name).Observe(time.Since(s.start).Seconds())
+	if code, enhanced, _ := parseReply(reply); code > 0 {
+		s.span.SetAttributes(attribute.Int("smtp.reply_code", code))
+		if enhanced != "" {
+			s.span.SetAttributes(attribute.String("smtp.enhanced_code", enhanced))
+		}
+	}
+	endSpan(s.span, err)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// One line of an SMTP session: "C" for what we sent, "S" for what the
+// server sent, "*" for connection events such as the TLS handshake
+type transcriptLine struct {
+	At        string `json:"at"` // millisecond timestamp
+	ElapsedMS int64  `json:"elapsed_ms"`
+	Dir       string `json:"dir"`
+	Line      string `json:"line"`
+}
+
+// Timestamped record of a session, shared by the plain and TLS conns
+type transcript struct {
+	start time.Time
+	lines []transcriptLine
+}
+
+func (t *transcript) add(dir, line string) {
+	now := time.Now()
+	t.lines = append(t.lines, transcriptLine{
+		At:        now.UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		ElapsedMS: now.Sub(t.start).Milliseconds(),
+		Dir:       dir,
+		Line:      line,
+	})
+}
+
+// Conn that records every line written and read into a transcript
+type transcriptConn struct {
+	net.Conn
+	t       *transcript
+	partial string // received bytes not yet ending in a newline
+}
+
+func (c *transcriptConn) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\r\n"), "\r\n") {
+		c.t.add("C", line)
+	}
+	return c.Conn.Write(p)
+}
+
+func (c *transcriptConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.partial += string(p[:n])
+	for {
+		line, rest, ok := strings.Cut(c.partial, "\n")
+		if !ok {
+			break
+		}
+		c.t.add("S", strings.TrimRight(line, "\r"))
+		c.partial = rest
+	}
+	return n, err
+}
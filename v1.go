@@ -0,0 +1,387 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stable response of POST /v1/verify. Fields are only ever added, never
+// renamed or removed; optional ones are omitted when unknown.
+type v1VerifyResponse struct {
+	Email            string            `json:"email"`
+	Status           string            `json:"status"`
+	Result           string            `json:"result"`      // deliverable, undeliverable, risky, accept_all, disposable, role, unknown, invalid_syntax or dns_error
+	ReplyClass       string            `json:"reply_class"` // deliverable, undeliverable, temp_failure or unknown
+	Score            int               `json:"score"`       // 0-100
+	Deliverable      bool              `json:"deliverable"`
+	Risky            bool              `json:"risky"`
+	CatchAll         bool              `json:"catch_all"`
+	Depth            string            `json:"depth,omitempty"`
+	SpamtrapRisk     string            `json:"spamtrap_risk,omitempty"`
+	DomainAgeDays    *int              `json:"domain_age_days,omitempty"`
+	IsParked         *bool             `json:"is_parked,omitempty"`
+	LowQualityRule   string            `json:"low_quality_rule,omitempty"`
+	Consensus        *v1Consensus      `json:"consensus,omitempty"`
+	MXHost           string            `json:"mx_host,omitempty"`
+	SMTPAttempts     int               `json:"smtp_attempts,omitempty"` // conversations tried, counting retries
+	Platform         string            `json:"platform,omitempty"`
+	Hint             string            `json:"hint,omitempty"`
+	Heuristic        string            `json:"heuristic,omitempty"` // why a platform rule adjusted the verdict
+	Reason           string            `json:"reason,omitempty"`
+	SyntaxError      string            `json:"syntax_error,omitempty"`
+	Suggestion       string            `json:"suggestion,omitempty"`
+	IsDisposable     bool              `json:"is_disposable"`
+	IsRoleAccount    bool              `json:"is_role_account"`
+	IsFreeProvider   bool              `json:"is_free_provider"`
+	IsToxic          bool              `json:"is_toxic"`
+	HasGravatar      *bool             `json:"has_gravatar,omitempty"` // only with GRAVATAR_CHECK=on
+	SPF              *v1SPF            `json:"spf,omitempty"`
+	DMARC            *v1DMARC          `json:"dmarc,omitempty"`
+	DKIM             *v1DKIM           `json:"dkim,omitempty"`
+	MTASTS           *v1MTASTS         `json:"mta_sts,omitempty"`
+	DANE             string            `json:"dane,omitempty"` // valid, invalid or absent
+	TLSCert          *v1TLSCert        `json:"tls_cert,omitempty"`
+	BlacklistedOn    []string          `json:"blacklisted_on,omitempty"`
+	NormalizedEmail  string            `json:"normalized_email"`
+	LocalPart        string            `json:"local_part"`
+	Domain           string            `json:"domain"`
+	DomainASCII      string            `json:"domain_ascii"`   // punycode, as used for DNS and SMTP
+	DomainUnicode    string            `json:"domain_unicode"` // for display
+	IsSubaddressed   bool              `json:"is_subaddressed"`
+	BaseEmail        string            `json:"base_email"` // without the +tag
+	SubaddressTag    string            `json:"subaddress_tag,omitempty"`
+	BaseVerified     bool              `json:"base_verified"` // verdict is that of BaseEmail
+	SMTP             *v1SMTP           `json:"smtp,omitempty"`
+	RCPT             *v1RCPT           `json:"rcpt,omitempty"`
+	VRFY             *v1VRFY           `json:"vrfy,omitempty"`     // only with VRFY_CHECK=on
+	SMTPLog          map[string]string `json:"smtp_log,omitempty"` // Deprecated: no longer populated, use SMTP and RCPT
+	FromCache        bool              `json:"from_cache"`
+	CachedAgeSeconds int               `json:"cached_age_seconds"`
+	Debug            gin.H             `json:"debug,omitempty"` // verbosity=debug only
+}
+
+// Body of POST /v1/verify with verbosity=minimal
+type v1MinimalResponse struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Score  int    `json:"score"`
+}
+
+type v1SMTP struct {
+	Connected     bool   `json:"connected"`
+	Port          int    `json:"port,omitempty"` // 25, or 587/465 when only submission answered
+	Banner        string `json:"banner"`
+	TLS           string `json:"tls"`
+	TLSError      string `json:"tls_error,omitempty"`
+	Proxy         string `json:"proxy,omitempty"`
+	SourceIP      string `json:"source_ip,omitempty"`
+	AddressFamily string `json:"address_family,omitempty"`
+	Helo          string `json:"helo,omitempty"`
+	MailFrom      string `json:"mail_from,omitempty"`
+}
+
+type v1SPF struct {
+	Exists   bool     `json:"exists"`
+	Record   string   `json:"record,omitempty"`
+	All      string   `json:"all,omitempty"`
+	Strict   bool     `json:"strict"`
+	Includes []string `json:"includes,omitempty"`
+	IPs      []string `json:"ips,omitempty"`
+}
+
+type v1DMARC struct {
+	Exists bool   `json:"exists"`
+	Record string `json:"record,omitempty"`
+	Policy string `json:"policy,omitempty"` // none, quarantine or reject
+	ADKIM  string `json:"adkim,omitempty"`
+	ASPF   string `json:"aspf,omitempty"`
+	RUA    string `json:"rua,omitempty"`
+}
+
+type v1DKIM struct {
+	SelectorsChecked []string `json:"selectors_checked"`
+	Found            []string `json:"found"`
+}
+
+type v1MTASTS struct {
+	Exists      bool     `json:"exists"`
+	Enforced    bool     `json:"enforced"`
+	Mode        string   `json:"mode,omitempty"` // enforce, testing or none
+	MX          []string `json:"mx,omitempty"`
+	MaxAge      int      `json:"max_age,omitempty"`
+	PolicyError string   `json:"policy_error,omitempty"`
+}
+
+type v1TLSCert struct {
+	Valid           bool      `json:"valid"` // trusted and matching the MX hostname
+	Trusted         bool      `json:"trusted"`
+	HostnameMatch   bool      `json:"hostname_match"`
+	Expired         bool      `json:"expired"`
+	SelfSigned      bool      `json:"self_signed"`
+	Subject         string    `json:"subject"`
+	Issuer          string    `json:"issuer"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	Error           string    `json:"error,omitempty"`
+}
+
+type v1Consensus struct {
+	MXHost string `json:"mx_host"`
+	Code   int    `json:"code"`   // 0 when the second host didn't answer
+	Agrees *bool  `json:"agrees"` // null without a second answer
+}
+
+type v1RCPT struct {
+	Code           int    `json:"code"`
+	EnhancedCode   string `json:"enhanced_code,omitempty"`
+	EnhancedReason string `json:"enhanced_reason,omitempty"` // e.g. mailbox_not_found, mailbox_full
+	Message        string `json:"message"`
+}
+
+type v1VRFY struct {
+	Command      string `json:"command"` // VRFY or EXPN
+	Code         int    `json:"code"`
+	EnhancedCode string `json:"enhanced_code,omitempty"`
+	Message      string `json:"message"`
+	Exists       *bool  `json:"exists"`
+}
+
+// Error body of the /v1 routes
+type v1Error struct {
+	Error      v1ErrorDetail `json:"error"`
+	Suggestion string        `json:"suggestion,omitempty"`
+	Partial    *v1Partial    `json:"partial,omitempty"`
+}
+
+// Findings of a verification cut off by the request deadline
+type v1Partial struct {
+	Stage  string           `json:"stage"` // dns, queue or smtp
+	Result v1VerifyResponse `json:"result"`
+}
+
+type v1ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+func newV1Error(code, message string) v1Error {
+	return v1Error{Error: v1ErrorDetail{code, message, retryableErrors[code]}}
+}
+
+func toV1Response(email string, res gin.H) v1VerifyResponse {
+	out := v1VerifyResponse{Email: email}
+	out.Status, _ = res["status"].(string)
+	out.Result, _ = res["result"].(string)
+	out.ReplyClass, _ = res["reply_class"].(string)
+	out.Score, _ = res["score"].(int)
+	out.Deliverable, _ = res["isDeliverable"].(bool)
+	out.Risky, _ = res["risky"].(bool)
+	out.CatchAll, _ = res["catch_all"].(bool)
+	out.MXHost, _ = res["mx_host"].(string)
+	out.Depth, _ = res["depth"].(string)
+	out.SpamtrapRisk, _ = res["spamtrap_risk"].(string)
+	if n, ok := res["domain_age_days"].(int); ok {
+		out.DomainAgeDays = &n
+	}
+	if b, ok := res["is_parked"].(bool); ok {
+		out.IsParked = &b
+	}
+	out.LowQualityRule, _ = res["low_quality_rule"].(string)
+	out.SMTPAttempts, _ = res["smtp_attempts"].(int)
+	out.Platform, _ = res["platform"].(string)
+	out.Hint, _ = res["hint"].(string)
+	out.Heuristic, _ = res["heuristic"].(string)
+	out.Reason, _ = res["reason"].(string)
+	out.SyntaxError, _ = res["syntax_error"].(string)
+	out.Suggestion, _ = res["suggestion"].(string)
+	out.IsDisposable, _ = res["is_disposable"].(bool)
+	out.IsRoleAccount, _ = res["is_role_account"].(bool)
+	out.IsFreeProvider, _ = res["is_free_provider"].(bool)
+	out.IsToxic, _ = res["is_toxic"].(bool)
+	if b, ok := res["has_gravatar"].(bool); ok {
+		out.HasGravatar = &b
+	}
+	out.NormalizedEmail, _ = res["normalized_email"].(string)
+	out.LocalPart, _ = res["local_part"].(string)
+	out.Domain, _ = res["domain"].(string)
+	out.DomainASCII, _ = res["domain_ascii"].(string)
+	out.DomainUnicode, _ = res["domain_unicode"].(string)
+	out.IsSubaddressed, _ = res["is_subaddressed"].(bool)
+	out.BaseEmail, _ = res["base_email"].(string)
+	out.SubaddressTag, _ = res["subaddress_tag"].(string)
+	out.BaseVerified, _ = res["base_verified"].(bool)
+	if smtp, ok := res["smtp"].(gin.H); ok {
+		out.SMTP = &v1SMTP{}
+		out.SMTP.Connected, _ = smtp["connected"].(bool)
+		out.SMTP.Port, _ = smtp["port"].(int)
+		out.SMTP.Banner, _ = smtp["banner"].(string)
+		out.SMTP.TLS, _ = smtp["tls"].(string)
+		out.SMTP.TLSError, _ = smtp["tls_error"].(string)
+		out.SMTP.Proxy, _ = smtp["proxy"].(string)
+		out.SMTP.SourceIP, _ = smtp["source_ip"].(string)
+		out.SMTP.AddressFamily, _ = smtp["address_family"].(string)
+		out.SMTP.Helo, _ = smtp["helo"].(string)
+		out.SMTP.MailFrom, _ = smtp["mail_from"].(string)
+	}
+	if rcpt, ok := res["rcpt"].(gin.H); ok {
+		out.RCPT = &v1RCPT{}
+		out.RCPT.Code, _ = rcpt["code"].(int)
+		out.RCPT.EnhancedCode, _ = rcpt["enhanced_code"].(string)
+		out.RCPT.EnhancedReason, _ = rcpt["enhanced_reason"].(string)
+		out.RCPT.Message, _ = rcpt["message"].(string)
+	}
+	if vrfy, ok := res["vrfy"].(gin.H); ok {
+		out.VRFY = &v1VRFY{}
+		out.VRFY.Command, _ = vrfy["command"].(string)
+		out.VRFY.Code, _ = vrfy["code"].(int)
+		out.VRFY.EnhancedCode, _ = vrfy["enhanced_code"].(string)
+		out.VRFY.Message, _ = vrfy["message"].(string)
+		if exists, ok := vrfy["exists"].(bool); ok {
+			out.VRFY.Exists = &exists
+		}
+	}
+	if spf, ok := res["spf"].(gin.H); ok {
+		out.SPF = &v1SPF{}
+		out.SPF.Exists, _ = spf["exists"].(bool)
+		out.SPF.Record, _ = spf["record"].(string)
+		out.SPF.All, _ = spf["all"].(string)
+		out.SPF.Strict, _ = spf["strict"].(bool)
+		out.SPF.Includes, _ = spf["includes"].([]string)
+		out.SPF.IPs, _ = spf["ips"].([]string)
+	}
+	if dmarc, ok := res["dmarc"].(gin.H); ok {
+		out.DMARC = &v1DMARC{}
+		out.DMARC.Exists, _ = dmarc["exists"].(bool)
+		out.DMARC.Record, _ = dmarc["record"].(string)
+		out.DMARC.Policy, _ = dmarc["policy"].(string)
+		out.DMARC.ADKIM, _ = dmarc["adkim"].(string)
+		out.DMARC.ASPF, _ = dmarc["aspf"].(string)
+		out.DMARC.RUA, _ = dmarc["rua"].(string)
+	}
+	if dkim, ok := res["dkim"].(gin.H); ok {
+		out.DKIM = &v1DKIM{}
+		out.DKIM.SelectorsChecked, _ = dkim["selectors_checked"].([]string)
+		out.DKIM.Found, _ = dkim["found"].([]string)
+	}
+	out.DANE, _ = res["dane"].(string)
+	if cert, ok := res["tls_cert"].(gin.H); ok {
+		out.TLSCert = &v1TLSCert{}
+		out.TLSCert.Valid, _ = cert["valid"].(bool)
+		out.TLSCert.Trusted, _ = cert["trusted"].(bool)
+		out.TLSCert.HostnameMatch, _ = cert["hostname_match"].(bool)
+		out.TLSCert.Expired, _ = cert["expired"].(bool)
+		out.TLSCert.SelfSigned, _ = cert["self_signed"].(bool)
+		out.TLSCert.Subject, _ = cert["subject"].(string)
+		out.TLSCert.Issuer, _ = cert["issuer"].(string)
+		out.TLSCert.NotAfter, _ = cert["not_after"].(time.Time)
+		out.TLSCert.DaysUntilExpiry, _ = cert["days_until_expiry"].(int)
+		out.TLSCert.Error, _ = cert["error"].(string)
+	}
+	out.BlacklistedOn, _ = res["blacklisted_on"].([]string)
+	if c, ok := res["consensus"].(gin.H); ok {
+		out.Consensus = &v1Consensus{}
+		out.Consensus.MXHost, _ = c["mx_host"].(string)
+		out.Consensus.Code, _ = c["code"].(int)
+		if b, ok := c["agrees"].(bool); ok {
+			out.Consensus.Agrees = &b
+		}
+	}
+	if sts, ok := res["mta_sts"].(gin.H); ok {
+		out.MTASTS = &v1MTASTS{}
+		out.MTASTS.Exists, _ = sts["exists"].(bool)
+		out.MTASTS.Enforced, _ = sts["enforced"].(bool)
+		out.MTASTS.PolicyError, _ = sts["policy_error"].(string)
+		if policy, ok := sts["policy"].(gin.H); ok {
+			out.MTASTS.Mode, _ = policy["mode"].(string)
+			out.MTASTS.MX, _ = policy["mx"].([]string)
+			out.MTASTS.MaxAge, _ = policy["max_age"].(int)
+		}
+	}
+	out.FromCache, _ = res["from_cache"].(bool)
+	out.CachedAgeSeconds, _ = res["cached_age_seconds"].(int)
+	return out
+}
+
+// GET /v1/verify?email=... or POST /v1/verify {"email": "..."}; mode=syntax
+// returns the syntaxResult instead, verbosity=minimal|standard|debug picks
+// the amount of detail
+func v1VerifyHandler(c *gin.Context) {
+	req, ok := requestEmail(c)
+	if !ok {
+		c.JSON(400, newV1Error("invalid_json", "Invalid JSON"))
+		return
+	}
+	if req.mode == "syntax" {
+		respond(c, 200, validateSyntax(req.email))
+		return
+	}
+	if !validVerbosity(req.verbosity) {
+		c.JSON(400, newV1Error("invalid_verbosity", "Invalid verbosity, use minimal, standard or debug"))
+		return
+	}
+	if !validDepth(req.depth) {
+		c.JSON(400, newV1Error("invalid_depth", "Invalid depth, use syntax, dns, smtp or deep"))
+		return
+	}
+	timeouts, ok := req.smtpTimeouts()
+	if !ok {
+		c.JSON(400, newV1Error("invalid_timeout", "Invalid timeout, use a duration up to "+maxSMTPTimeout.String()+" such as 10s"))
+		return
+	}
+	if _, ok := findProxy(req.proxy); req.proxy != "" && !ok {
+		c.JSON(400, newV1Error("invalid_proxy", "Unknown proxy"))
+		return
+	}
+	if req.mailFrom != "" && !validSender(req.mailFrom) {
+		c.JSON(400, newV1Error("invalid_mail_from", "Invalid mail_from, use an email address or <>"))
+		return
+	}
+	if req.helo != "" && !validHeloName(req.helo) {
+		c.JSON(400, newV1Error("invalid_helo", "Invalid helo, use a domain name or address literal"))
+		return
+	}
+	email, ok := cleanEmail(req.email)
+	if !ok {
+		c.JSON(400, newV1Error("invalid_email", "Invalid email"))
+		return
+	}
+	if !chargeQuota(c, 1) {
+		return
+	}
+
+	status, res := checkRequested(c.Request.Context(), email, req.verifyBase, req.checkOptions(timeouts))
+	setCacheHeaders(c, res)
+	if code, msg, failed := resultError(res); failed {
+		out := newV1Error(code, msg)
+		out.Suggestion, _ = res["suggestion"].(string)
+		if p, ok := res["partial"].(gin.H); ok {
+			out.Partial = &v1Partial{}
+			out.Partial.Stage, _ = p["stage"].(string)
+			partial, _ := p["result"].(gin.H)
+			out.Partial.Result = toV1Response(email, partial)
+		}
+		respond(c, status, out)
+		return
+	}
+	switch req.verbosity {
+	case "minimal":
+		respond(c, status, v1MinimalResponse{email, resultStatus(res), resultScore(res)})
+	case "debug":
+		out := toV1Response(email, res)
+		out.Debug, _ = res["debug"].(gin.H)
+		respond(c, status, out)
+	default:
+		respond(c, status, toV1Response(email, res))
+	}
+}
+
+// Mark a legacy route as deprecated in favour of its /v1 successor
+func deprecatedRoute(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
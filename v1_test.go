@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// result is the canonical verdict on v1 as everywhere else; the reply
+// family travels as reply_class
+func TestV1ResultIsCanonical(t *testing.T) {
+	out := toV1Response("info@example.com", gin.H{"reply_class": "temp_failure", "result": "role"})
+	if out.Result != "role" {
+		t.Errorf("result = %q, want role", out.Result)
+	}
+	if out.ReplyClass != "temp_failure" {
+		t.Errorf("reply_class = %q, want temp_failure", out.ReplyClass)
+	}
+}
+
+// gRPC and GraphQL results are built from the v1 body and must carry what
+// it carries
+func TestTypedMappersFollowV1(t *testing.T) {
+	agrees := false
+	res := gin.H{
+		"reply_class": "deliverable",
+		"result":      "accept_all",
+		"risky":       true,
+		"smtp": gin.H{
+			"connected": true, "port": 25, "proxy": "eu", "source_ip": "192.0.2.7",
+			"address_family": "ipv6", "helo": "probe.example.com", "mail_from": "<>",
+		},
+		"consensus": gin.H{"mx_host": "mx2.example.com", "code": 550, "agrees": agrees},
+	}
+	pb := resultToProto("1", "a@example.com", res)
+	gql := toGQLEmailResult("a@example.com", res)
+
+	if pb.Result != "accept_all" || pb.ReplyClass != "deliverable" || !pb.Risky {
+		t.Errorf("proto result/reply_class/risky = %q/%q/%v", pb.Result, pb.ReplyClass, pb.Risky)
+	}
+	if *gql.Result != "accept_all" || *gql.ReplyClass != "deliverable" || !gql.Risky {
+		t.Errorf("graphql result/replyClass/risky = %q/%q/%v", *gql.Result, *gql.ReplyClass, gql.Risky)
+	}
+	s := pb.Smtp
+	if s.Proxy != "eu" || s.SourceIp != "192.0.2.7" || s.AddressFamily != "ipv6" || s.Helo != "probe.example.com" || s.MailFrom != "<>" {
+		t.Errorf("proto smtp = %v", s)
+	}
+	g := gql.Smtp
+	if *g.Proxy != "eu" || *g.SourceIp != "192.0.2.7" || *g.AddressFamily != "ipv6" || *g.Helo != "probe.example.com" || *g.MailFrom != "<>" {
+		t.Errorf("graphql smtp = %+v", g)
+	}
+	if c := pb.Consensus; c == nil || c.MxHost != "mx2.example.com" || c.Code != 550 || c.Agrees == nil || *c.Agrees {
+		t.Errorf("proto consensus = %v", c)
+	}
+	if c := gql.Consensus; c == nil || c.MxHost != "mx2.example.com" || c.Code != 550 || c.Agrees == nil || *c.Agrees {
+		t.Errorf("graphql consensus = %+v", c)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "github.com/gin-gonic/gin"
+
+// Report whether v is a known verbosity; empty means standard
+func validVerbosity(v string) bool {
+	switch v {
+	case "", "minimal", "standard", "debug":
+		return true
+	}
+	return false
+}
+
+// Trim a result body to the requested verbosity: minimal keeps the status
+// and score, standard drops the debug block, debug returns everything
+func applyVerbosity(res gin.H, verbosity string) gin.H {
+	switch verbosity {
+	case "debug":
+		return res
+	case "minimal":
+		out := gin.H{"email": res["normalized_email"], "status": resultStatus(res), "score": resultScore(res)}
+		if e, ok := res["error"]; ok {
+			out["error"] = e
+		}
+		return out
+	}
+	if _, ok := res["debug"]; !ok {
+		return res
+	}
+	out := make(gin.H, len(res))
+	for k, v := range res {
+		if k != "debug" {
+			out[k] = v
+		}
+	}
+	return out
+}
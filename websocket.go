@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Checks a single WebSocket connection or gRPC stream may have in flight
+var wsMaxInFlight = 4
+
+// Origins browsers may open /ws from besides the service's own
+// (WS_ALLOWED_ORIGINS), "*" for any. Clients sending no Origin, i.e. anything
+// but a browser, are always let in.
+var wsAllowedOrigins []string
+
+var wsUpgrader = websocket.Upgrader{CheckOrigin: checkWSOrigin}
+
+// Report whether origin is a bare scheme://host[:port], or "*"
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+func parseWSAllowedOrigins(v string) error {
+	origins, err := identityList(v, validOrigin, "origins such as https://app.example.com, or *")
+	for i, o := range origins {
+		origins[i] = strings.ToLower(o)
+	}
+	wsAllowedOrigins = origins
+	return err
+}
+
+// Let a WebSocket handshake through when it comes from the service's own
+// origin, an allowed one or a client that sends no Origin at all
+func checkWSOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.Contains(wsAllowedOrigins, "*") || slices.Contains(wsAllowedOrigins, strings.ToLower(origin))
+}
+
+// GET /ws
+//
+// Clients send {"id": "...", "email": "..."} messages and receive
+// {"id": "...", "email": "...", "result": {...}} replies as checks finish,
+// not necessarily in the order they were sent.
+func wsHandler(c *gin.Context) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade already wrote the HTTP error
+	}
+	defer conn.Close()
+	// Messages are held to the same size as request bodies; a larger one
+	// closes the connection
+	conn.SetReadLimit(int64(maxRequestBody))
+
+	var writeMu sync.Mutex
+	send := func(msg gin.H) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.WriteJSON(msg)
+	}
+
+	sem := make(chan struct{}, wsMaxInFlight)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// Abort in-flight checks once the client goes away
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	for {
+		var req struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return // closed by the client or broken connection
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			send(gin.H{"error": apiError("invalid_json", "Invalid JSON")})
+			continue
+		}
+
+		email, ok := cleanEmail(req.Email)
+		if !ok {
+			send(gin.H{"id": req.ID, "email": req.Email, "error": apiError("invalid_email", "Invalid email")})
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(id, email string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if failed := chargeQuotaCtx(ctx, email); failed != nil {
+				send(gin.H{"id": id, "email": email, "error": failed["error"]})
+				return
+			}
+			status, res := cachedCheck(ctx, email, func() (int, gin.H) { return checkEmail(ctx, email, defaultCheckOptions()) })
+			msg := gin.H{"id": id, "email": email, "result": applyVerbosity(res, "standard")}
+			if status != 200 {
+				msg["http_status"] = status
+			}
+			send(msg)
+		}(req.ID, email)
+	}
+}
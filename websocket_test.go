@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckWSOrigin(t *testing.T) {
+	old := wsAllowedOrigins
+	t.Cleanup(func() { wsAllowedOrigins = old })
+	if err := parseWSAllowedOrigins("https://App.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://verifier.example.com:8080", true},
+		{"https://app.example.com", true},
+		{"https://evil.example.net", false},
+		{"null", false},
+	} {
+		r := httptest.NewRequest("GET", "http://verifier.example.com:8080/ws", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := checkWSOrigin(r); got != tc.want {
+			t.Errorf("Origin %q: allowed = %v, want %v", tc.origin, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"app.example.com", "https://app.example.com/page", ""} {
+		if parseWSAllowedOrigins(bad) == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestWSReadLimit(t *testing.T) {
+	old := maxRequestBody
+	t.Cleanup(func() { maxRequestBody = old })
+	maxRequestBody = 1024
+	r := gin.New()
+	r.GET("/ws", wsHandler)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	big := `{"id": "1", "email": "` + strings.Repeat("a", 2048) + `@example.com"}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(big)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("err = %v, want close %d", err, websocket.CloseMessageTooBig)
+	}
+}